@@ -0,0 +1,140 @@
+package imgix
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Canonicalize returns the canonical form of an imgix URL, such that two
+// URLs that render the same image have the same canonical form. This makes
+// it suitable as a cache key or for deduplicating stored URLs.
+//
+// The canonical form always uses https and a lowercase domain. The ixlib
+// and signature ("s") params are dropped, aliases are replaced with their
+// canonical keys, and params are sorted by key. The items of lists whose
+// order is insignificant, e.g. auto=format,compress, are sorted and
+// deduplicated; those of positional lists, e.g. rect and duotone, keep
+// their order. Numbers are formatted with as few digits as represent them
+// exactly (e.g. dpr=2.0 becomes dpr=2). Note that the canonical form is
+// not signed, so it may not be a working URL.
+//
+// The returned error matches ErrInvalidURL if the URL cannot be parsed,
+// or ErrConflictingAlias if an alias and its canonical key are both given
+// with different values.
+func Canonicalize(rawURL string) (string, error) {
+	p, err := ParseURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	params := p.Params
+	delete(params, "ixlib")
+
+	if err := normalizeAliases(params); err != nil {
+		return "", err
+	}
+
+	for k, values := range params {
+		params[k] = canonicalValues(k, values)
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	dst := append(*buf, "https://"...)
+	dst = append(dst, strings.ToLower(p.Domain)...)
+	dst = appendSanitizedPath(dst, p.Path)
+	if len(params) > 0 {
+		var keys [16]string
+		dst = append(dst, '?')
+		dst, _ = appendEncodedQuery(dst, params, keys[:0])
+	}
+	*buf = dst
+	return string(dst), nil
+}
+
+// Equal reports whether two imgix URLs render the same image, i.e.
+// whether they have the same canonical form. See Canonicalize.
+func Equal(a string, b string) (bool, error) {
+	ca, err := Canonicalize(a)
+	if err != nil {
+		return false, err
+	}
+
+	cb, err := Canonicalize(b)
+	if err != nil {
+		return false, err
+	}
+	return ca == cb, nil
+}
+
+// canonicalValues returns the canonical form of a param's values, given
+// its canonical key. Params that are not in the spec are left unchanged.
+func canonicalValues(key string, values []string) []string {
+	spec, ok := specOf(key)
+	if !ok || len(spec.expects) == 0 {
+		return values
+	}
+
+	switch spec.expects[0].kind {
+	case kindList:
+		if spec.unordered {
+			return canonicalSet(values)
+		}
+		return canonicalList(values)
+	case kindInteger, kindNumber, kindUnitScalar:
+		if len(values) == 1 {
+			return []string{canonicalNumber(values[0])}
+		}
+	}
+	return values
+}
+
+// canonicalList splits comma-separated values into their items, in order,
+// and formats the items that are decimal numbers, e.g. rect=0.50,10,10,10
+// becomes rect=0.5,10,10,10. Other items, including colors like 000080,
+// are left unchanged.
+func canonicalList(values []string) []string {
+	var items []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if strings.Contains(item, ".") {
+				item = canonicalNumber(item)
+			}
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// canonicalSet splits comma-separated values into their items, then sorts
+// and dedupes them. It is used for lists whose order is insignificant,
+// e.g. auto=format,compress.
+func canonicalSet(values []string) []string {
+	var items []string
+	for _, v := range values {
+		items = append(items, strings.Split(v, ",")...)
+	}
+	sort.Strings(items)
+
+	unique := items[:0]
+	for idx, item := range items {
+		if idx > 0 && item == items[idx-1] {
+			continue
+		}
+		unique = append(unique, item)
+	}
+	return unique
+}
+
+// canonicalNumber formats a number with as few digits as represent it
+// exactly. Values that are not numbers are returned unchanged.
+func canonicalNumber(value string) string {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return value
+	}
+	return formatFloat(f)
+}
@@ -0,0 +1,143 @@
+package imgix
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanonical_Canonicalize(t *testing.T) {
+	ub := NewURLBuilder("Test.imgix.net", WithToken("FOO123bar"))
+	rawURL := ub.CreateURL("users/1.png",
+		Param("w", "320.0"),
+		Param("dpr", "2.50"),
+		Param("auto", "format,compress", "format"),
+		Param("orient", "6"),
+		Param("txt64", "Hello, World!"))
+
+	got, err := Canonicalize(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "https://test.imgix.net/users/1.png?auto=compress%2Cformat&dpr=2.5&or=6&txt64=SGVsbG8sIFdvcmxkIQ&w=320"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestCanonical_CanonicalizeProxy(t *testing.T) {
+	const rawURL = "http://test.imgix.net/http%3A%2F%2Favatars.com%2Fjohn-smith.png?s=abc"
+	got, err := Canonicalize(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = "https://test.imgix.net/http%3A%2F%2Favatars.com%2Fjohn-smith.png"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestCanonical_CanonicalizeLists(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// The items of unordered lists are sorted and deduped.
+		{"auto=format,compress,format", "auto=compress%2Cformat"},
+		{"mark-align=top,left", "mark-align=left%2Ctop"},
+		// Positional lists keep their order and repeated items, and only
+		// decimal numbers are reformatted.
+		{"rect=10,10,100,100", "rect=10%2C10%2C100%2C100"},
+		{"rect=20,100.0,300,400", "rect=20%2C100%2C300%2C400"},
+		{"duotone=000080,FA8072", "duotone=000080%2CFA8072"},
+		// Keys that name no base64 variant are left unchanged.
+		{"w64=320.0", "w64=320.0"},
+	}
+
+	for _, tt := range tests {
+		got, err := Canonicalize("https://test.imgix.net/image.png?" + tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "https://test.imgix.net/image.png?" + tt.want
+		if got != want {
+			t.Errorf("\ngot:  %s\nwant: %s", got, want)
+		}
+	}
+}
+
+func TestCanonical_CanonicalizeKeepsUnknownParams(t *testing.T) {
+	got, err := Canonicalize("https://test.imgix.net/image.png?foo=2.0,1&w=100")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const want = "https://test.imgix.net/image.png?foo=2.0%2C1&w=100"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestCanonical_CanonicalizeInvalid(t *testing.T) {
+	_, err := Canonicalize("ftp://test.imgix.net/image.png")
+	if !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrInvalidURL)
+	}
+
+	_, err = Canonicalize("https://test.imgix.net/image.png?or=6&orient=8")
+	if !errors.Is(err, ErrConflictingAlias) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrConflictingAlias)
+	}
+}
+
+func TestCanonical_Equal(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{
+			"https://test.imgix.net/image.png?auto=format%2Ccompress&w=320&ixlib=go-v2.0.2",
+			"http://test.imgix.net/image.png?w=320.0&auto=compress,format&s=0123",
+			true,
+		},
+		{
+			"https://test.imgix.net/image.png?orient=6",
+			"https://test.imgix.net/image.png?or=6",
+			true,
+		},
+		{
+			"https://test.imgix.net/image.png?w=320",
+			"https://test.imgix.net/image.png?w=321",
+			false,
+		},
+		{
+			"https://test.imgix.net/image.png?rect=20,100,300,400",
+			"https://test.imgix.net/image.png?rect=100,20,300,400",
+			false,
+		},
+		{
+			"https://test.imgix.net/image.png?duotone=red,blue",
+			"https://test.imgix.net/image.png?duotone=blue,red",
+			false,
+		},
+		{
+			"https://test.imgix.net/image.png",
+			"https://other.imgix.net/image.png",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Equal(tt.a, tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.want {
+			t.Errorf("Equal(%s, %s)\ngot:  %t\nwant: %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
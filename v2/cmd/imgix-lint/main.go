@@ -0,0 +1,80 @@
+// Command imgix-lint checks imgix URLs for params that have no effect or
+// that conflict, using the same rules as imgix.Lint.
+//
+// Usage:
+//
+//	imgix-lint [url ...]
+//
+// If no URLs are given, they are read from standard input, one per line.
+// Each warning is printed to standard output as "url: rule: message", and
+// each URL that cannot be parsed is reported on standard error. The exit
+// status is 2 if the input cannot be read or any URL cannot be parsed, 1
+// if any URL has warnings, and 0 otherwise.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	imgix "github.com/imgix/imgix-go/v2"
+)
+
+func main() {
+	var urls []string
+	if len(os.Args) > 1 {
+		urls = os.Args[1:]
+	} else {
+		var err error
+		urls, err = readURLs(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitInvalid)
+		}
+	}
+
+	os.Exit(lint(os.Stdout, os.Stderr, urls))
+}
+
+// The exit statuses of imgix-lint.
+const (
+	exitOK       = 0 // Every URL is free of warnings.
+	exitWarnings = 1 // Some URL has warnings.
+	exitInvalid  = 2 // The input cannot be read, or some URL cannot be parsed.
+)
+
+// readURLs reads one URL per line, skipping blank lines.
+func readURLs(r io.Reader) ([]string, error) {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			urls = append(urls, line)
+		}
+	}
+	return urls, scanner.Err()
+}
+
+// lint writes the warnings of each URL to w, and the errors of the URLs
+// that cannot be parsed to errW. It returns the exit status.
+func lint(w io.Writer, errW io.Writer, urls []string) int {
+	status := exitOK
+	for _, rawURL := range urls {
+		warnings, err := imgix.LintURL(rawURL)
+		if err != nil {
+			fmt.Fprintf(errW, "%s: %v\n", rawURL, err)
+			status = exitInvalid
+			continue
+		}
+
+		for _, warning := range warnings {
+			fmt.Fprintf(w, "%s: %s\n", rawURL, warning)
+			if status == exitOK {
+				status = exitWarnings
+			}
+		}
+	}
+	return status
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLint_readURLs(t *testing.T) {
+	input := "https://test.imgix.net/a.png\n\n  https://test.imgix.net/b.png  \n"
+	got, err := readURLs(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "https://test.imgix.net/a.png,https://test.imgix.net/b.png"
+	if strings.Join(got, ",") != want {
+		t.Errorf("\ngot:  %v\nwant: %s", got, want)
+	}
+}
+
+func TestLint_lint(t *testing.T) {
+	var buf, errBuf bytes.Buffer
+	status := lint(&buf, &errBuf, []string{
+		"https://test.imgix.net/a.png?w=320&fit=crop&crop=faces",
+		"https://test.imgix.net/b.png?crop=faces",
+	})
+
+	if status != exitWarnings {
+		t.Errorf("\ngot:  %d\nwant: %d", status, exitWarnings)
+	}
+
+	want := "https://test.imgix.net/b.png?crop=faces: missing-dependency: `crop` has no effect without `fit=crop`\n"
+	if buf.String() != want {
+		t.Errorf("\ngot:  %s\nwant: %s", buf.String(), want)
+	}
+
+	if errBuf.Len() != 0 {
+		t.Errorf("\ngot:  %s\nwant: no errors", errBuf.String())
+	}
+}
+
+func TestLint_lintInvalid(t *testing.T) {
+	var buf, errBuf bytes.Buffer
+	status := lint(&buf, &errBuf, []string{
+		"ftp://test.imgix.net/c.png",
+		"https://test.imgix.net/b.png?crop=faces",
+	})
+
+	// A URL that cannot be parsed takes precedence over warnings.
+	if status != exitInvalid {
+		t.Errorf("\ngot:  %d\nwant: %d", status, exitInvalid)
+	}
+
+	want := "ftp://test.imgix.net/c.png: imgix: invalid URL \"ftp://test.imgix.net/c.png\": scheme must be http or https\n"
+	if errBuf.String() != want {
+		t.Errorf("\ngot:  %s\nwant: %s", errBuf.String(), want)
+	}
+
+	if strings.Contains(buf.String(), "ftp://") {
+		t.Errorf("\ngot:  %s\nwant: no parse errors on stdout", buf.String())
+	}
+}
+
+func TestLint_lintClean(t *testing.T) {
+	var buf, errBuf bytes.Buffer
+	if status := lint(&buf, &errBuf, []string{"https://test.imgix.net/a.png?w=320"}); status != exitOK {
+		t.Errorf("\ngot:  %d %s\nwant: no warnings", status, buf.String())
+	}
+}
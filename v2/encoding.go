@@ -1,9 +1,7 @@
 package imgix
 
 import (
-	"crypto/md5"
 	"encoding/base64"
-	"encoding/hex"
 	"net/url"
 	"sort"
 	"strings"
@@ -74,97 +72,177 @@ func encodeProxy(proxyPath string, isEncoded bool) (escapedProxyPath string) {
 	return escapedProxyPath
 }
 
-// encodePath uses splitAndEscape to encode the given path string into
-// a form that can be safely placed inside a URL path segment. The path
-// passed to this func should be prefixed with a '/', but if it isn't
-// this function produces the same output.
-func encodePath(path string) string {
+// upperhex contains the digits used to percent-encode a byte.
+const upperhex = "0123456789ABCDEF"
+
+// appendEncodedPath appends the encoded form of the given path to dst.
+// Each of the path's components is escaped as url.PathEscape would
+// escape it, except that '+' is also escaped to "%2B". The path passed
+// to this func should be prefixed with a '/', but if it isn't this
+// function produces the same output.
+func appendEncodedPath(dst []byte, path string) []byte {
+	dst = append(dst, '/')
 	if strings.HasPrefix(path, "/") {
-		escapedPath := splitAndEscape(path[1:])
-		return "/" + escapedPath
+		path = path[1:]
+	}
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || !shouldEscapePathByte(c) {
+			dst = append(dst, c)
+			continue
+		}
+		dst = append(dst, '%', upperhex[c>>4], upperhex[c&15])
 	}
-	return "/" + splitAndEscape(path)
+	return dst
 }
 
-// splitAndEscape splits the path on forward slash characters,
-// PathEscape's each component, replaces any '+' with "%2B", then
-// appends this escaped component to the results array. The result
-// is then joined together using '/' as the delimeter.
-func splitAndEscape(path string) string {
-	if path == "" {
-		return path
+// shouldEscapePathByte reports whether c must be percent-encoded within
+// a path component. Only unreserved characters and the sub-delimiters
+// url.PathEscape leaves alone, less '+', are left unescaped.
+func shouldEscapePathByte(c byte) bool {
+	if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+		return false
 	}
 
-	var result []string
-	splitPath := strings.Split(path, "/")
+	switch c {
+	case '-', '_', '.', '~', '$', '&', ':', '=', '@':
+		return false
+	}
+	return true
+}
 
-	for _, component := range splitPath {
-		c := url.PathEscape(component)
-		pathEscaped := strings.ReplaceAll(c, "+", "%2B")
-		result = append(result, pathEscaped)
+// appendEncodedQuery appends the encoded form of params to dst, in a form
+// that can be safely used within the query string of a URL. Params are
+// sorted by key. The keys slice is used as scratch space for sorting, and
+// is returned so that its storage can be reused.
+func appendEncodedQuery(dst []byte, params url.Values, keys []string) ([]byte, []string) {
+	keys = keys[:0]
+	for k := range params {
+		keys = append(keys, k)
 	}
+	sortKeys(keys)
 
-	return strings.Join(result, "/")
+	for idx, k := range keys {
+		if idx > 0 {
+			dst = append(dst, '&')
+		}
+		dst = appendEncodedQueryParam(dst, k, params[k])
+	}
+	return dst, keys
 }
 
-// encodeQueryString encodes a set of params into a form that can be
-// safely used within the query string of a URL.
-func encodeQuery(params url.Values) (encodedQueryParts []string) {
+// sortKeys sorts keys in increasing order. Queries usually have only a
+// handful of params, so an insertion sort is used rather than sort.Strings,
+// which would cause keys to escape to the heap.
+func sortKeys(keys []string) {
+	for i := 1; i < len(keys); i++ {
+		for j := i; j > 0 && keys[j] < keys[j-1]; j-- {
+			keys[j], keys[j-1] = keys[j-1], keys[j]
+		}
+	}
+}
 
-	keys := make([]string, 0, len(params))
+// appendEncodedQueryParam appends a key and values to dst in forms that
+// can be safely placed within a URL query string. Multiple values are
+// joined together by commas and treated as a single value. If the key is
+// the base64 variant of a param (e.g. "txt64"), then its corresponding
+// value will be base64 encoded in a way that's safe for URLs.
+func appendEncodedQueryParam(dst []byte, key string, values []string) []byte {
+	dst = appendQueryEscaped(dst, key)
+	dst = append(dst, '=')
 
-	for k := range params {
-		keys = append(keys, k)
+	if isBase64(key) {
+		value := strings.Join(values, ",")
+		return append(dst, base64EncodeQueryParamValue(value)...)
 	}
-	sort.Strings(keys)
 
-	for _, k := range keys {
-		encodedKey, encodedValue := encodeQueryParam(k, params[k])
-		encodedPairStr := strings.Join([]string{encodedKey, encodedValue}, "=")
-		encodedQueryParts = append(encodedQueryParts, encodedPairStr)
+	for idx, value := range values {
+		if idx > 0 {
+			// The comma is escaped, just as url.QueryEscape would escape it.
+			dst = append(dst, "%2C"...)
+		}
+		dst = appendQueryEscaped(dst, value)
 	}
-	return encodedQueryParts
+	return dst
 }
 
-// encodedQueryParam encodes a key and values into forms that can be
-// safely placed within a URL query string. If the key has been
-// suffixed with the base64 suffix, "64" (e.g. "text64"), then its
-// corresponding value will be base64 encoded in a way that's safe
-// for URLs.
-func encodeQueryParam(key string, values []string) (eK string, eV string) {
-	eK = encodeQueryParamValue(key)
+// appendQueryEscaped appends s to dst, escaped as url.QueryEscape would
+// escape it. Note that net/url uses plus (+) as SPACE and does not
+// percent-encode ' ' to "%20".
+func appendQueryEscaped(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9':
+			dst = append(dst, c)
+		case c == '-' || c == '_' || c == '.' || c == '~':
+			dst = append(dst, c)
+		case c == ' ':
+			dst = append(dst, '+')
+		default:
+			dst = append(dst, '%', upperhex[c>>4], upperhex[c&15])
+		}
+	}
+	return dst
+}
 
-	valuesLength := len(values)
+// noBase64Key is the url.Values key under which Base64Param records the
+// params it was given that have no base64 variant. It is removed, and the
+// params reported, before the query is encoded (see takeNoBase64).
+const noBase64Key = "\x00base64"
 
-	// If there are multiple values, then join them together
-	// and then treat them as a single value.
-	var value string
-	if valuesLength > 1 {
-		value = strings.Join(values, ",")
-	} else if valuesLength == 1 {
-		value = values[0]
+// takeNoBase64 removes the record of params that were given to Base64Param
+// but have no base64 variant. If there are any, a *ParamsError rejecting
+// each of them with ErrParamNotBase64 is returned.
+func takeNoBase64(params url.Values) error {
+	keys := params[noBase64Key]
+	if len(keys) == 0 {
+		return nil
 	}
+	delete(params, noBase64Key)
 
-	if isBase64(key) {
-		eV = base64EncodeQueryParamValue(value)
-		return eK, eV
+	sort.Strings(keys)
+	var problems []*ParamError
+	for idx, k := range keys {
+		if idx > 0 && k == keys[idx-1] {
+			continue
+		}
+		problems = append(problems, &ParamError{
+			Key:   k + "64",
+			Value: strings.Join(params[k], ","),
+			Err:   ErrParamNotBase64})
 	}
+	return &ParamsError{Errors: problems}
+}
 
-	eV = encodeQueryParamValue(value)
-	return eK, eV
+// isBase64 checks if the paramKey is the base64 variant of a param, i.e.
+// that the value is intended to be base64-URL-encoded. The key must be
+// suffixed by "64" and the rest of it must name a param that has a base64
+// variant (see hasBase64Variant), so that a key like "w64" is left alone.
+func isBase64(paramKey string) bool {
+	base := strings.TrimSuffix(paramKey, "64")
+	return base != paramKey && hasBase64Variant(base)
 }
 
-// encodeQueryParamValue uses url.QueryEscape to escape the queryValue
-// into a form that is safe to use in URLs. Note that net/url uses
-// plus (+) as SPACE and does not percent-encode '+' to "%20".
-func encodeQueryParamValue(queryValue string) string {
-	return url.QueryEscape(queryValue)
+// hasBase64Variant reports whether a param, or the param an alias refers
+// to, is documented as having a base64 variant in the imgix parameter spec
+// (e.g. txt, txt-font, mark, blend, and mask). The paramSpecs table is the
+// registry of these params.
+func hasBase64Variant(paramKey string) bool {
+	return paramSpecs[canonicalKey(paramKey)].base64
 }
 
-// isBase64 checks if the paramKey is suffixed by "64," indicating
-// that the value is intended to be base64-URL-encoded.
-func isBase64(paramKey string) bool {
-	return strings.HasSuffix(paramKey, "64")
+// needsBase64 reports whether a value contains characters that are not
+// printable ASCII, e.g. emoji or non-Latin text, which are safest passed
+// to imgix base64-encoded.
+func needsBase64(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c < 0x20 || c >= 0x7f {
+			return true
+		}
+	}
+	return false
 }
 
 // base64EncodeQueryParamValue base64 encodes the queryValue string. It
@@ -179,6 +257,16 @@ func base64EncodeQueryParamValue(queryValue string) string {
 	return unPadBase64Value(maybePaddedValue)
 }
 
+// base64DecodeQueryParamValue reverses base64EncodeQueryParamValue. Padded
+// values are accepted as well as unpadded ones.
+func base64DecodeQueryParamValue(encodedValue string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(unPadBase64Value(encodedValue))
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
 // unPadBase64Value removes the extra '=' (equal signs) from strings.
 // In base64, '=' are added to the end of the encoding as padding.
 // This padding is significant if concatenating multiple base64-encoded
@@ -192,23 +280,3 @@ func unPadBase64Value(s string) string {
 	}
 	return s
 }
-
-// createMd5Signature creates the signature by joining the token, path, and params
-// strings into a signatureBase. Next, create a hashedSig and write the
-// signatureBase into it. Finally, return the encoded, signed string.
-func createMd5Signature(token string, path string, query string) string {
-	var delim string
-
-	if query == "" {
-		delim = ""
-	} else {
-		delim = "?"
-	}
-
-	// The expected signature base has the form:
-	// {TOKEN}{PATH}{DELIM}{QUERY}
-	signatureBase := strings.Join([]string{token, path, delim, query}, "")
-	hashedSig := md5.New()
-	hashedSig.Write([]byte(signatureBase))
-	return hex.EncodeToString(hashedSig.Sum(nil))
-}
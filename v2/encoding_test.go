@@ -2,51 +2,45 @@ package imgix
 
 import (
 	"encoding/base64"
+	"net/url"
+	"strings"
 	"testing"
 )
 
 func TestEncoding_isBase64(t *testing.T) {
-	gotBase64 := isBase64("64")
-	if !gotBase64 {
-		t.Errorf("got:  %t; want: %t", gotBase64, true)
-	}
-
-	gotBase64 = isBase64("   64")
-	if !gotBase64 {
-		t.Errorf("got:  %t; want: %t", gotBase64, true)
-	}
-
-	gotBase64 = isBase64("646464")
-	if !gotBase64 {
-		t.Errorf("got:  %t; want: %t", gotBase64, true)
-	}
-
-	gotBase64 = isBase64("fit64")
-	if !gotBase64 {
-		t.Errorf("got:  %t; want: %t", gotBase64, true)
-	}
-	gotBase64 = isBase64("markalign64")
-	if !gotBase64 {
-		t.Errorf("got:  %t; want: %t", gotBase64, true)
+	// Ensure the base64 variants of documented params, and of
+	// their aliases, are accepted as base64 keys.
+	for _, key := range []string{"txt64", "txt-font64", "txtfont64", "mark64", "blend64", "mask64"} {
+		gotBase64 := isBase64(key)
+		if !gotBase64 {
+			t.Errorf("%s: got:  %t; want: %t", key, gotBase64, true)
+		}
 	}
 }
 
 func TestEncoding_isNotBase64(t *testing.T) {
 	// Ensure the following strings are NOT accepted as
-	// valid base64 keys.
-	gotBase64 := isBase64("6  4")
-	if gotBase64 {
-		t.Errorf("got:  %t; want: %t", gotBase64, true)
+	// valid base64 keys, including params that merely end
+	// in "64" and params without a base64 variant.
+	for _, key := range []string{"64", "   64", "646464", "fit64", "markalign64", "w64", "txt", "txt64 ", "6  4", "\x40"} {
+		gotBase64 := isBase64(key)
+		if gotBase64 {
+			t.Errorf("%q: got:  %t; want: %t", key, gotBase64, false)
+		}
 	}
+}
 
-	gotBase64 = isBase64("646464 ")
-	if gotBase64 {
-		t.Errorf("got:  %t; want: %t", gotBase64, true)
+func TestEncoding_needsBase64(t *testing.T) {
+	for _, value := range []string{"Hello, 世界", "😱", "line\nbreak"} {
+		if !needsBase64(value) {
+			t.Errorf("%q: got:  %t; want: %t", value, false, true)
+		}
 	}
 
-	gotBase64 = isBase64("\x40")
-	if gotBase64 {
-		t.Errorf("got:  %t; want: %t", gotBase64, true)
+	for _, value := range []string{"", "Hello, World!", "Avenir Next Demi,Bold", "+&#%"} {
+		if needsBase64(value) {
+			t.Errorf("%q: got:  %t; want: %t", value, true, false)
+		}
 	}
 }
 
@@ -192,3 +186,27 @@ func TestEncoding_encodeUnicode(t *testing.T) {
 		t.Errorf("\ngot:  %s\nwant: %s", got, want)
 	}
 }
+
+// TestEncoding_escapeEveryByte checks that the append-based encoders
+// escape every byte just as net/url does, with the exception that paths
+// also escape '+' to "%2B".
+func TestEncoding_escapeEveryByte(t *testing.T) {
+	for c := 0; c < 256; c++ {
+		s := string([]byte{byte(c)})
+
+		wantPath := "/" + strings.ReplaceAll(url.PathEscape(s), "+", "%2B")
+		if c == '/' {
+			wantPath = "//"
+		}
+		gotPath := string(appendEncodedPath(nil, "/"+s))
+		if gotPath != wantPath {
+			t.Errorf("\ngot:  %s\nwant: %s", gotPath, wantPath)
+		}
+
+		wantQuery := url.QueryEscape(s)
+		gotQuery := string(appendQueryEscaped(nil, s))
+		if gotQuery != wantQuery {
+			t.Errorf("\ngot:  %s\nwant: %s", gotQuery, wantQuery)
+		}
+	}
+}
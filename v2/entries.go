@@ -0,0 +1,198 @@
+package imgix
+
+import (
+	"log"
+	"net/url"
+	"strconv"
+)
+
+// The separators between the image candidate strings of a srcset that
+// SrcsetEntries.Join accepts. CreateSrcset uses SrcsetNewlineSeparator.
+const (
+	SrcsetNewlineSeparator = ",\n"
+	SrcsetSpaceSeparator   = ", "
+)
+
+// SrcsetDescriptor is the kind of descriptor of an image candidate: a
+// width, e.g. "320w", or a pixel density, e.g. "2x".
+type SrcsetDescriptor byte
+
+// The kinds of descriptors of image candidates.
+const (
+	WidthDescriptor   SrcsetDescriptor = 'w'
+	DensityDescriptor SrcsetDescriptor = 'x'
+)
+
+func (d SrcsetDescriptor) String() string {
+	return string(d)
+}
+
+// SrcsetEntry is a single image candidate of a srcset.
+type SrcsetEntry struct {
+	URL        string           // The candidate's URL.
+	Descriptor SrcsetDescriptor // The kind of the candidate's descriptor.
+	Value      float64          // The width or pixel density of the descriptor.
+	Params     url.Values       // The params used to build the URL, without ixlib or s.
+}
+
+// String returns the image candidate string of the entry, e.g.
+// "https://test.imgix.net/image.png?w=320 320w".
+func (e SrcsetEntry) String() string {
+	return string(e.appendTo(nil))
+}
+
+// appendTo appends the image candidate string of the entry to dst.
+func (e SrcsetEntry) appendTo(dst []byte) []byte {
+	dst = append(dst, e.URL...)
+	return appendDescriptor(dst, e.Descriptor, e.Value)
+}
+
+// SrcsetEntries are the image candidates of a srcset, in order.
+type SrcsetEntries []SrcsetEntry
+
+// String returns the srcset attribute of the entries, in the format
+// returned by CreateSrcset.
+func (entries SrcsetEntries) String() string {
+	return entries.Join(SrcsetNewlineSeparator)
+}
+
+// Join returns the srcset attribute of the entries, with the image
+// candidate strings separated by separator, e.g. SrcsetSpaceSeparator.
+func (entries SrcsetEntries) Join(separator string) string {
+	var dst []byte
+	for idx, e := range entries {
+		if idx > 0 {
+			dst = append(dst, separator...)
+		}
+		dst = e.appendTo(dst)
+	}
+	return string(dst)
+}
+
+// CreateSrcsetEntries functions like CreateSrcset except that it returns
+// the image candidates of the srcset as entries, rather than joining them
+// into a single string.
+//
+// If the params cannot be applied, CreateSrcsetEntries returns nil, as
+// CreateSrcset returns an empty string. If the width range or another
+// option is invalid, CreateSrcsetEntries calls log.Fatal. Use
+// CreateSrcsetEntriesE to handle either error instead.
+func (b *URLBuilder) CreateSrcsetEntries(
+	path string,
+	params []IxParam,
+	options ...SrcsetOption) SrcsetEntries {
+
+	urlParams, info, err := b.buildValues(params)
+	if err != nil {
+		return nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	var entries SrcsetEntries
+	srcset, err := b.appendSrcsetValues(*buf, path, urlParams, info, options, &entries)
+	*buf = srcset
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return entries
+}
+
+// CreateSrcsetEntriesE functions like CreateSrcsetEntries except that it
+// returns an error, rather than exiting. See CreateSrcsetE for the errors
+// returned.
+func (b *URLBuilder) CreateSrcsetEntriesE(
+	path string,
+	params []IxParam,
+	options ...SrcsetOption) (SrcsetEntries, error) {
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	var entries SrcsetEntries
+	srcset, err := b.appendSrcset(*buf, path, params, options, &entries)
+	*buf = srcset
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// CreateSrcsetFromWidthsEntries functions like CreateSrcsetFromWidths
+// except that it returns the image candidates of the srcset as entries.
+// If a width is negative or the params cannot be applied, it returns nil,
+// as CreateSrcsetFromWidths returns an empty string; use
+// CreateSrcsetFromWidthsEntriesE to find out why.
+func (b *URLBuilder) CreateSrcsetFromWidthsEntries(path string, params []IxParam, widths []int) SrcsetEntries {
+	entries, _ := b.CreateSrcsetFromWidthsEntriesE(path, params, widths)
+	return entries
+}
+
+// CreateSrcsetFromWidthsEntriesE functions like
+// CreateSrcsetFromWidthsEntries except that it returns an error if a width
+// is negative or the params cannot be applied (see CreateURLE).
+func (b *URLBuilder) CreateSrcsetFromWidthsEntriesE(path string, params []IxParam, widths []int) (SrcsetEntries, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	var entries SrcsetEntries
+	srcset, err := b.appendSrcsetFromWidths(*buf, path, params, widths, &entries)
+	*buf = srcset
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// appendCandidate appends an image candidate string, made of the URL
+// built from the path and params and the given descriptor, to dst. If
+// entries is not nil, an entry for the candidate is appended to it.
+func (b *URLBuilder) appendCandidate(
+	dst []byte,
+	path []byte,
+	params url.Values,
+	descriptor SrcsetDescriptor,
+	value float64,
+	entries *SrcsetEntries) []byte {
+
+	params = b.withoutDefaults(params)
+
+	start := len(dst)
+	dst = b.appendURL(dst, path, params)
+
+	if entries != nil {
+		*entries = append(*entries, SrcsetEntry{
+			URL:        string(dst[start:]),
+			Descriptor: descriptor,
+			Value:      value,
+			Params:     candidateParams(params)})
+	}
+	return appendDescriptor(dst, descriptor, value)
+}
+
+// appendDescriptor appends a space and the descriptor of an image
+// candidate, e.g. " 320w" or " 1.5x", to dst.
+func appendDescriptor(dst []byte, descriptor SrcsetDescriptor, value float64) []byte {
+	dst = append(dst, ' ')
+	if descriptor == WidthDescriptor {
+		dst = strconv.AppendInt(dst, int64(value), 10)
+	} else {
+		dst = append(dst, formatDPR(value)...)
+	}
+	return append(dst, byte(descriptor))
+}
+
+// candidateParams returns a copy of the params of an image candidate,
+// without the ixlib param. The values are copied, since the srcset
+// builders reuse them for every candidate.
+func candidateParams(params url.Values) url.Values {
+	copied := make(url.Values, len(params))
+	for k, v := range params {
+		if k == "ixlib" {
+			continue
+		}
+		copied[k] = append([]string(nil), v...)
+	}
+	return copied
+}
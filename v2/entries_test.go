@@ -0,0 +1,78 @@
+package imgix
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEntries_CreateSrcsetEntries(t *testing.T) {
+	c := testClientWithToken()
+	params := []IxParam{Param("w", "320")}
+
+	entries := c.CreateSrcsetEntries("image.png", params, WithDevicePixelRatios(1, 1.5))
+	if len(entries) != 2 {
+		t.Fatalf("got: %d entries; want: 2 entries", len(entries))
+	}
+
+	want := c.CreateSrcset("image.png", params, WithDevicePixelRatios(1, 1.5))
+	if got := entries.String(); got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	e := entries[1]
+	if e.Descriptor != DensityDescriptor || e.Value != 1.5 {
+		t.Errorf("got: %v%s; want: 1.5x", e.Value, e.Descriptor)
+	}
+
+	if got := e.Params.Encode(); got != "dpr=1.5&q=63&w=320" {
+		t.Errorf("\ngot:  %s\nwant: %s", got, "dpr=1.5&q=63&w=320")
+	}
+
+	if err := c.Verify(e.URL); err != nil {
+		t.Errorf("%s\ngot: err == %v; want: err == nil", e.URL, err)
+	}
+}
+
+func TestEntries_CreateSrcsetFromWidthsEntries(t *testing.T) {
+	c := testClient()
+
+	entries := c.CreateSrcsetFromWidthsEntries("image.jpg", []IxParam{Param("auto", "format")}, []int{100, 200})
+	want := []SrcsetEntry{
+		{"https://test.imgix.net/image.jpg?auto=format&w=100", WidthDescriptor, 100, nil},
+		{"https://test.imgix.net/image.jpg?auto=format&w=200", WidthDescriptor, 200, nil},
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("got: %d entries; want: %d entries", len(entries), len(want))
+	}
+
+	for idx, e := range entries {
+		if e.URL != want[idx].URL || e.Descriptor != want[idx].Descriptor || e.Value != want[idx].Value {
+			t.Errorf("\ngot:  %s\nwant: %s", e, want[idx])
+		}
+	}
+
+	// The params of each entry are its own.
+	if got := entries[0].Params.Get("w"); got != "100" {
+		t.Errorf("got: w=%s; want: w=100", got)
+	}
+
+	got := entries.Join(SrcsetSpaceSeparator)
+	wantJoined := "https://test.imgix.net/image.jpg?auto=format&w=100 100w, " +
+		"https://test.imgix.net/image.jpg?auto=format&w=200 200w"
+	if got != wantJoined {
+		t.Errorf("\ngot:  %s\nwant: %s", got, wantJoined)
+	}
+}
+
+func TestEntries_CreateSrcsetEntriesE(t *testing.T) {
+	c := testClient()
+	entries, err := c.CreateSrcsetEntriesE("image.png", []IxParam{}, WithTolerance(0.001))
+	if !errors.Is(err, ErrInvalidTolerance) {
+		t.Errorf("got: %v; want: errors.Is(err, ErrInvalidTolerance)", err)
+	}
+
+	if entries != nil {
+		t.Errorf("got: %v; want: nil entries", entries)
+	}
+}
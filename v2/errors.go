@@ -0,0 +1,176 @@
+package imgix
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidDomain is returned when a URLBuilder is given a domain it
+// cannot build URLs with. Every DomainError matches ErrInvalidDomain
+// when compared with errors.Is.
+var ErrInvalidDomain = errors.New("imgix: invalid domain")
+
+// The following errors describe why a domain was rejected by strict
+// domain validation (see WithStrictDomain). They are wrapped by a
+// DomainError.
+var (
+	ErrDomainEmpty            = errors.New("imgix: domain must not be empty")
+	ErrDomainHasScheme        = errors.New("imgix: domain must not contain a scheme")
+	ErrDomainHasUserinfo      = errors.New("imgix: domain must not contain credentials")
+	ErrDomainHasPort          = errors.New("imgix: domain must not contain a port")
+	ErrDomainHasPath          = errors.New("imgix: domain must not contain a path")
+	ErrDomainHasQuery         = errors.New("imgix: domain must not contain a query")
+	ErrDomainHasFragment      = errors.New("imgix: domain must not contain a fragment")
+	ErrDomainEmptyLabel       = errors.New("imgix: domain must not contain an empty label")
+	ErrDomainInvalidCharacter = errors.New("imgix: domain must contain only letters, digits, hyphens, and dots")
+)
+
+// ErrDomainNotSharded describes a domain that was given a token by
+// WithDomainToken but is not one of the builder's sharded domains. It is
+// wrapped by a DomainError.
+var ErrDomainNotSharded = errors.New("imgix: domain was given a token but is not one of the sharded domains")
+
+// ErrInvalidBaseURL is returned when the base URL given to WithBaseURL
+// is not an absolute http or https URL, or when it contains credentials,
+// a query, or a fragment.
+var ErrInvalidBaseURL = errors.New("imgix: invalid base URL")
+
+// ErrInvalidURL is returned when a URL cannot be parsed as an imgix URL.
+var ErrInvalidURL = errors.New("imgix: invalid URL")
+
+// The following errors are returned when a URL's signature cannot be
+// verified. See Verifier.
+var (
+	ErrMissingToken       = errors.New("imgix: missing token")
+	ErrMissingSignature   = errors.New("imgix: missing signature")
+	ErrMalformedSignature = errors.New("imgix: malformed signature")
+	ErrSignatureMismatch  = errors.New("imgix: signature mismatch")
+	ErrMalformedExpires   = errors.New("imgix: malformed expires timestamp")
+	ErrExpired            = errors.New("imgix: URL expired")
+)
+
+// ErrUnknownPreset is returned when a param references a preset that has
+// not been registered with WithPreset.
+var ErrUnknownPreset = errors.New("imgix: unknown preset")
+
+// ErrInvalidWidthRange is returned when a srcset width-range is invalid,
+// e.g. when a width is negative or the range is decreasing.
+var ErrInvalidWidthRange = errors.New("imgix: invalid width range")
+
+// ErrInvalidWidth is returned when a width given to
+// CreateSrcsetFromWidthsE is negative.
+var ErrInvalidWidth = errors.New("imgix: invalid width")
+
+// ErrInvalidTolerance is returned when a srcset width tolerance is
+// less than one percent (0.01).
+var ErrInvalidTolerance = errors.New("imgix: invalid width tolerance")
+
+// ErrInvalidDPR is returned when a device pixel ratio given to
+// WithDevicePixelRatios is not greater than zero and at most 10, or when
+// no ratios are given.
+var ErrInvalidDPR = errors.New("imgix: invalid device pixel ratio")
+
+// ErrInvalidQuality is returned when the quality of a device pixel ratio
+// given by WithDPRQualities or WithDPRQualityFunc is not between 0 and 100.
+var ErrInvalidQuality = errors.New("imgix: invalid quality")
+
+// ErrInvalidAspectRatio is returned when the aspect ratio given to
+// WithAspectRatio is not of the form W:H, where W and H are positive
+// numbers.
+var ErrInvalidAspectRatio = errors.New("imgix: invalid aspect ratio")
+
+// ErrInvalidIntrinsicSize is returned when the width or height given to
+// WithIntrinsicSize is negative.
+var ErrInvalidIntrinsicSize = errors.New("imgix: invalid intrinsic size")
+
+// ErrInvalidParams is returned when strict param validation (see
+// WithStrictParams) finds a problem with a URL's params. Every ParamsError
+// matches ErrInvalidParams when compared with errors.Is.
+var ErrInvalidParams = errors.New("imgix: invalid params")
+
+// The following errors describe why a param was rejected by strict param
+// validation, or, for ErrParamNotBase64, given to Base64Param without
+// having a base64 variant. They are wrapped by a ParamError.
+var (
+	ErrUnknownParam    = errors.New("unknown param")
+	ErrParamNotBase64  = errors.New("param has no base64 variant")
+	ErrParamNotAllowed = errors.New("value is not one of the documented values")
+	ErrParamOutOfRange = errors.New("value is out of range")
+	ErrParamMalformed  = errors.New("value is malformed")
+)
+
+// ErrConflictingAlias is returned when alias normalization (see
+// WithAliasNormalization) finds both an alias and its canonical key with
+// different values, e.g. "orient=6" and "or=8".
+var ErrConflictingAlias = errors.New("imgix: conflicting alias")
+
+// DomainError records a domain that was rejected and the reason
+// it was rejected.
+type DomainError struct {
+	Domain string // The domain as it was given to the builder.
+	Err    error  // The reason the domain was rejected.
+}
+
+func (e *DomainError) Error() string {
+	// The reason has its own "imgix: " prefix, which is not repeated.
+	reason := strings.TrimPrefix(e.Err.Error(), "imgix: ")
+	return ErrInvalidDomain.Error() + " " + strconv.Quote(e.Domain) + ": " + reason
+}
+
+// Unwrap returns the reason the domain was rejected.
+func (e *DomainError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidDomain.
+func (e *DomainError) Is(target error) bool {
+	return target == ErrInvalidDomain
+}
+
+// ParamError records a param that was rejected by strict param validation
+// and the reason it was rejected.
+type ParamError struct {
+	Key   string // The param's key, e.g. "q".
+	Value string // The param's values, joined by commas.
+	Err   error  // The reason the param was rejected.
+}
+
+func (e *ParamError) Error() string {
+	return "`" + e.Key + "=" + e.Value + "`: " + e.Err.Error()
+}
+
+// Unwrap returns the reason the param was rejected.
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// ParamsError records every param of a URL that was rejected by strict
+// param validation, sorted by key, or that was given to Base64Param
+// without having a base64 variant.
+type ParamsError struct {
+	Errors []*ParamError
+}
+
+func (e *ParamsError) Error() string {
+	problems := make([]string, len(e.Errors))
+	for idx, err := range e.Errors {
+		problems[idx] = err.Error()
+	}
+	return ErrInvalidParams.Error() + ": " + strings.Join(problems, "; ")
+}
+
+// Is reports whether target is ErrInvalidParams, or whether any of the
+// rejected params matches target, e.g. ErrParamOutOfRange.
+func (e *ParamsError) Is(target error) bool {
+	if target == ErrInvalidParams {
+		return true
+	}
+
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
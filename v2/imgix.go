@@ -1,19 +1,53 @@
 package imgix
 
 import (
+	"fmt"
 	"log"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const ixLibVersion = "go-v2.0.2"
 
 // URLBuilder facilitates the building of imgix URLs.
+//
+// The Create* methods of a URLBuilder are safe for concurrent use. The
+// Set* methods are not, and must not be called while the builder is in
+// use by other goroutines; use With to derive a modified copy instead.
 type URLBuilder struct {
 	domain      string // A source's domain, e.g. example.imgix.net
 	token       string // A source's secure token used to sign/secure URLs.
+	signer      Signer // Signs URLs; nil if URLs are not signed.
 	useHTTPS    bool   // Denotes whether or not to use HTTPS.
 	useLibParam bool   // Denotes whether or not to apply the ixLibVersion.
+
+	previousTokens []string  // Rotated-out tokens that are still accepted by Verify.
+	defaultParams  []IxParam // Params applied to every URL the builder creates.
+
+	presets       map[string]preset // Named bundles of params registered by WithPreset.
+	paramDefaults map[string]string // Defaults registered by WithParamDefault.
+
+	strictDomain bool   // Denotes whether or not to strictly validate the domain.
+	strictParams bool   // Denotes whether or not to validate params against the spec.
+	normalize    bool   // Denotes whether or not to replace aliases with canonical keys.
+	dropDefaults bool   // Denotes whether or not to drop params equal to their defaults.
+	promote64    bool   // Denotes whether or not to promote values to base64 variants.
+	customSigner Signer // A Signer given to WithSigner, replacing MD5 signing.
+	baseURL      string // A full base URL that replaces the scheme and domain.
+	baseScheme   string // The scheme taken from the baseURL, overriding useHTTPS.
+	baseHost     string // The host and port taken from the baseURL.
+	pathPrefix   string // A path prefix taken from the baseURL, e.g. /imgix
+
+	domains []string // The domains given to the constructor, before validation.
+	sharded bool     // Denotes whether or not the builder was constructed sharded.
+
+	shards        []shard           // The domains of a sharded builder.
+	shardTokens   map[string]string // Per-domain tokens given to WithDomainToken.
+	shardStrategy ShardStrategy     // How a domain is picked from the shards.
+	shardCounter  *uint32           // Counts URLs for the ShardRoundRobin strategy.
 }
 
 // BuilderOption provides a convenient interface for supplying URLBuilder
@@ -22,20 +56,116 @@ type URLBuilder struct {
 type BuilderOption func(b *URLBuilder)
 
 // NewURLBuilder creates a new URLBuilder with the given domain, with HTTPS enabled.
+// If the domain is invalid, NewURLBuilder calls log.Fatal; use NewURLBuilderE
+// to handle the error instead.
 func NewURLBuilder(domain string, options ...BuilderOption) URLBuilder {
-	validDomain, err := validateDomain(domain)
+	urlBuilder, err := NewURLBuilderE(domain, options...)
 	if err != nil {
 		log.Fatal(err)
 	}
+	return urlBuilder
+}
 
-	urlBuilder := URLBuilder{domain: validDomain, useHTTPS: true, useLibParam: true}
+// NewURLBuilderE creates a new URLBuilder with the given domain, with HTTPS
+// enabled. If the domain is invalid, the returned error is a *DomainError.
+func NewURLBuilderE(domain string, options ...BuilderOption) (URLBuilder, error) {
+	urlBuilder := URLBuilder{
+		useHTTPS:    true,
+		useLibParam: true,
+		domains:     []string{domain}}
 
 	for _, fn := range options {
 		fn(&urlBuilder)
 	}
+
+	if err := urlBuilder.init(); err != nil {
+		return URLBuilder{}, err
+	}
+	return urlBuilder, nil
+}
+
+// With returns a copy of the builder with the given options applied; the
+// builder itself is left unchanged. Unlike the Set* methods, With is safe
+// to call while the builder is in use by other goroutines, e.g.
+//
+//	httpBuilder := b.With(WithHTTPS(false))
+//
+// If the options are invalid, With calls log.Fatal; use WithE to handle
+// the error instead.
+func (b *URLBuilder) With(options ...BuilderOption) URLBuilder {
+	urlBuilder, err := b.WithE(options...)
+	if err != nil {
+		log.Fatal(err)
+	}
 	return urlBuilder
 }
 
+// WithE functions like With except that it returns an error, rather than
+// exiting, if the options are invalid.
+func (b *URLBuilder) WithE(options ...BuilderOption) (URLBuilder, error) {
+	urlBuilder := *b
+
+	for _, fn := range options {
+		fn(&urlBuilder)
+	}
+
+	if err := urlBuilder.init(); err != nil {
+		return URLBuilder{}, err
+	}
+	return urlBuilder, nil
+}
+
+// init validates the builder's domains and derives the state that
+// depends on them. It runs once the builder's options have been applied,
+// both when a builder is constructed and when one is derived by With.
+func (b *URLBuilder) init() error {
+	b.signer = b.newSigner(b.token)
+
+	if !b.sharded {
+		if err := b.checkShardTokens(nil); err != nil {
+			return err
+		}
+	}
+
+	b.baseScheme, b.baseHost, b.pathPrefix = "", "", ""
+	if b.baseURL != "" {
+		if err := b.applyBaseURL(); err != nil {
+			return err
+		}
+		if b.sharded {
+			return fmt.Errorf(
+				"%w %q: cannot be combined with domain sharding", ErrInvalidBaseURL, b.baseURL)
+		}
+		b.domain = b.baseHost
+		return nil
+	}
+
+	domain := b.domain
+	if len(b.domains) > 0 {
+		domain = b.domains[0]
+	}
+
+	validDomain, err := b.checkDomain(domain)
+	if err != nil {
+		return err
+	}
+	b.domain = validDomain
+
+	if b.sharded {
+		return b.initShards()
+	}
+	return nil
+}
+
+// checkDomain validates the domain, strictly if the builder was given
+// WithStrictDomain(true).
+func (b *URLBuilder) checkDomain(domain string) (string, error) {
+	if b.strictDomain {
+		return validateDomainStrict(domain)
+	}
+	return validateDomain(domain)
+}
+
 // WithToken returns a BuilderOption that NewURLBuilder consumes.
 // The constructor uses this closure to set the URLBuilder's token
 // attribute.
@@ -45,6 +175,32 @@ func WithToken(token string) BuilderOption {
 	}
 }
 
+// WithPreviousTokens returns a BuilderOption that NewURLBuilder consumes.
+// URLs are always signed with the builder's token, but URLs signed with
+// any of the previous tokens are still accepted by Verify. This allows a
+// source's token to be rotated without breaking URLs already issued; see
+// also Resign. The tokens are copied, so the caller may reuse the slice.
+func WithPreviousTokens(tokens ...string) BuilderOption {
+	tokens = append(make([]string, 0, len(tokens)), tokens...)
+	return func(b *URLBuilder) {
+		b.previousTokens = tokens
+	}
+}
+
+// WithDefaultParams returns a BuilderOption that NewURLBuilder consumes.
+// The default params are applied to every URL created by CreateURL,
+// CreateSrcset, and CreateSrcsetFromWidths. A param passed to one of these
+// methods replaces the default param with the same key, and Without
+// removes a default param altogether. A default q is not applied to the
+// fixed-width srcsets of CreateSrcset while variable quality is enabled
+// (see WithVariableQuality), so that each device pixel ratio keeps its
+// own quality; a q passed to CreateSrcset still applies.
+func WithDefaultParams(params ...IxParam) BuilderOption {
+	return func(b *URLBuilder) {
+		b.defaultParams = params
+	}
+}
+
 // WithHTTPS returns a BuilderOption that NewURLBuilder consumes.
 // The constructor uses this closure to set the URLBuilder's useHTTPS
 // attribute.
@@ -63,20 +219,185 @@ func WithLibParam(useLibParam bool) BuilderOption {
 	}
 }
 
-// UseHTTPS returns whether HTTPS or HTTP should be used.
+// WithStrictDomain returns a BuilderOption that NewURLBuilder consumes.
+// When strictDomain is true, the constructor rejects any domain that is
+// not a bare hostname (e.g. "example.imgix.net") instead of stripping the
+// scheme, port, path, and query from it. The returned error wraps one of
+// ErrDomainHasScheme, ErrDomainHasPath, etc. describing the problem.
+func WithStrictDomain(strictDomain bool) BuilderOption {
+	return func(b *URLBuilder) {
+		b.strictDomain = strictDomain
+	}
+}
+
+// WithStrictParams returns a BuilderOption that NewURLBuilder consumes.
+// When strictParams is true, every param is checked against the imgix
+// parameter spec (see ParamSpecVersion) before a URL or srcset is built.
+// Unknown keys, values that are not among a param's documented values,
+// out-of-range numbers, malformed values (e.g. colors), and the "64"
+// suffix on params without a base64 variant are all rejected. The E
+// variants of the Create methods then return a *ParamsError that lists
+// every problem found, and the other Create methods return an empty
+// result (an empty string, or nil entries) rather than a URL built from
+// the invalid params. Strict validation applies to every Create method.
+//
+// Params that the vendored spec does not list are rejected as unknown, so
+// strict validation is only as complete as the spec; see ParamSpecVersion
+// and spec/README.md for how to update it.
+func WithStrictParams(strictParams bool) BuilderOption {
+	return func(b *URLBuilder) {
+		b.strictParams = strictParams
+	}
+}
+
+// WithAliasNormalization returns a BuilderOption that NewURLBuilder
+// consumes. When normalize is true, param aliases (e.g. "orient" and
+// "markalign") are replaced with their canonical keys ("or" and
+// "mark-align"), so that the same rendering always produces the same URL.
+// Each layer of params (see WithDefaultParams and WithPreset) is
+// normalized on its own, so a canonical key still replaces an alias set
+// by an earlier layer. If an alias and its canonical key are both given in
+// the same layer with different values, the E variants of the Create
+// methods return an error matching ErrConflictingAlias, and the other
+// Create methods return an empty result.
+func WithAliasNormalization(normalize bool) BuilderOption {
+	return func(b *URLBuilder) {
+		b.normalize = normalize
+	}
+}
+
+// WithDropDefaults returns a BuilderOption that NewURLBuilder consumes.
+// When dropDefaults is true, params whose values equal the defaults imgix
+// uses when they are absent (e.g. dpr=1 or fit=clip) are removed before
+// the URL is signed, so that identical renderings share a URL. Numbers
+// are compared by value, so dpr=1.0 is dropped too. Each srcset candidate
+// is compared once its w, dpr, and q are set, so an explicit q=75 still
+// overrides variable quality. See ParamDefaults for the documented
+// defaults, and WithParamDefault to extend them.
+func WithDropDefaults(dropDefaults bool) BuilderOption {
+	return func(b *URLBuilder) {
+		b.dropDefaults = dropDefaults
+	}
+}
+
+// WithParamDefault returns a BuilderOption that NewURLBuilder consumes.
+// It registers the default value of a param for WithDropDefaults, adding
+// to, or overriding, the documented defaults returned by ParamDefaults.
+// This is useful for params that are missing from the spec, or for
+// source-level defaults configured in the imgix dashboard.
+func WithParamDefault(key string, value string) BuilderOption {
+	return func(b *URLBuilder) {
+		paramDefaults := make(map[string]string, len(b.paramDefaults)+1)
+		for k, v := range b.paramDefaults {
+			paramDefaults[k] = v
+		}
+		paramDefaults[key] = value
+		b.paramDefaults = paramDefaults
+	}
+}
+
+// WithBase64Promotion returns a BuilderOption that NewURLBuilder consumes.
+// When promote is true, a param that has a base64 variant (see
+// Base64Param) is replaced with that variant if its value contains
+// characters that are not printable ASCII, e.g. txt=Hello, 世界 becomes
+// txt64=SGVsbG8sIOS4lueVjA. A param whose base64 variant is also given is
+// left alone.
+func WithBase64Promotion(promote bool) BuilderOption {
+	return func(b *URLBuilder) {
+		b.promote64 = promote
+	}
+}
+
+// WithSigner returns a BuilderOption that NewURLBuilder consumes. URLs are
+// signed by the given Signer rather than with the MD5 scheme, e.g. by a
+// deterministic fake in tests or an HMAC-SHA256 signer for a self-hosted,
+// imgix-compatible origin. The signer is used for every domain of a
+// sharded builder, and URLs are signed even if no token is given. Passing
+// nil restores MD5 signing with the builder's token.
+func WithSigner(signer Signer) BuilderOption {
+	return func(b *URLBuilder) {
+		b.customSigner = signer
+	}
+}
+
+// newSigner returns the Signer for URLs signed with the given token: the
+// builder's custom signer, if it has one, or else an MD5 signer. If there
+// is neither a custom signer nor a token, nil is returned.
+func (b *URLBuilder) newSigner(token string) Signer {
+	if b.customSigner != nil {
+		return b.customSigner
+	}
+
+	if token == "" {
+		return nil
+	}
+	return newMD5Signer(token)
+}
+
+// WithBaseURL returns a BuilderOption that NewURLBuilder consumes. The
+// base URL replaces the builder's scheme and domain, and may carry a port
+// and a path prefix, e.g. "http://localhost:8080/imgix". This is useful
+// for pointing a builder at a local stand-in for imgix. The path prefix
+// is part of every URL's path, and so it is covered by the signature.
+//
+// When a base URL is given, the domain passed to NewURLBuilder is ignored,
+// and so is WithHTTPS, whatever the order of the options. Passing an empty
+// base URL to With restores the builder's domain and scheme.
+func WithBaseURL(baseURL string) BuilderOption {
+	return func(b *URLBuilder) {
+		b.baseURL = baseURL
+	}
+}
+
+// applyBaseURL parses the builder's baseURL and uses it to set the
+// builder's scheme, host (and port), and path prefix. It is called by
+// init, once every option has been applied.
+func (b *URLBuilder) applyBaseURL() error {
+	u, err := url.Parse(b.baseURL)
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidBaseURL, b.baseURL, err)
+	}
+
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return fmt.Errorf("%w %q: scheme must be http or https", ErrInvalidBaseURL, b.baseURL)
+	case u.Host == "":
+		return fmt.Errorf("%w %q: host must not be empty", ErrInvalidBaseURL, b.baseURL)
+	case u.User != nil:
+		return fmt.Errorf("%w %q: must not contain credentials", ErrInvalidBaseURL, b.baseURL)
+	case u.RawQuery != "" || u.ForceQuery:
+		return fmt.Errorf("%w %q: must not contain a query", ErrInvalidBaseURL, b.baseURL)
+	case u.Fragment != "":
+		return fmt.Errorf("%w %q: must not contain a fragment", ErrInvalidBaseURL, b.baseURL)
+	}
+
+	b.baseScheme = u.Scheme
+	b.baseHost = u.Host
+	b.pathPrefix = strings.TrimSuffix(u.EscapedPath(), "/")
+	return nil
+}
+
+// UseHTTPS returns whether HTTPS or HTTP should be used. If the builder
+// was given a base URL, its scheme decides.
 func (b *URLBuilder) UseHTTPS() bool {
+	if b.baseScheme != "" {
+		return b.baseScheme == "https"
+	}
 	return b.useHTTPS
 }
 
 // SetUseLibParam toggles the library param on and off. If useLibParam is set to
 // true, the ixlib param will be toggled on. Otherwise, if useLibParam is set to
 // false, the ixlib param will be toggled off and will not appear in the final URL.
+// SetUseLibParam is not safe for concurrent use; see With.
 func (b *URLBuilder) SetUseLibParam(useLibParam bool) {
 	b.useLibParam = useLibParam
 }
 
 // SetUseHTTPS sets a builder's useHTTPS field to true or false. Setting
-// useHTTPS to false forces the builder to use HTTP.
+// useHTTPS to false forces the builder to use HTTP, unless it was given a
+// base URL (see WithBaseURL). SetUseHTTPS is not safe for concurrent use;
+// see With.
 func (b *URLBuilder) SetUseHTTPS(useHTTPS bool) {
 	b.useHTTPS = useHTTPS
 }
@@ -90,15 +411,18 @@ func (b *URLBuilder) Scheme() string {
 	return "http"
 }
 
-// Domain gets the builder's domain string.
+// Domain gets the builder's domain string. If the builder was given a
+// base URL, the domain includes the base URL's port, if any.
 func (b *URLBuilder) Domain() string {
 	return b.domain
 }
 
 // SetToken sets the token for this builder. This value will be used to sign
-// URLs created through the builder.
+// URLs created through the builder. SetToken is not safe for concurrent use;
+// see With.
 func (b *URLBuilder) SetToken(token string) {
 	b.token = token
+	b.signer = b.newSigner(token)
 }
 
 // IxParam seeks to improve the ergonomics of setting url.Values.
@@ -120,119 +444,296 @@ func Param(k string, v ...string) IxParam {
 	}
 }
 
+// Base64Param functions like Param except that it sets the base64 variant
+// of the param, e.g. Base64Param("txt", "Hello, 世界") sets "txt64", whose
+// value is base64-encoded when the URL is created. Only params documented
+// as having a base64 variant (txt, txt-font, mark, blend, and mask) have
+// one. Any other param, e.g. w, cannot be set this way: CreateURLE and the
+// other E-suffixed methods return an error matching ErrParamNotBase64 and
+// ErrInvalidParams, and CreateURL and the other methods that do not return
+// errors build no URL.
+func Base64Param(k string, v ...string) IxParam {
+	if hasBase64Variant(k) {
+		return Param(k+"64", v...)
+	}
+
+	return func(u *url.Values) {
+		u.Add(noBase64Key, k)
+		for _, value := range v {
+			u.Add(k, value)
+		}
+	}
+}
+
+// ExpiresAt returns an IxParam that sets the "expires" param to the
+// given time as a UNIX timestamp. imgix refuses to serve a signed URL
+// once it has expired. Since params are applied before the URL is
+// signed, the expiry is covered by the signature.
+func ExpiresAt(t time.Time) IxParam {
+	return Param("expires", strconv.FormatInt(t.Unix(), 10))
+}
+
+// ExpiresIn returns an IxParam that sets the "expires" param to the given
+// duration from the time the param is applied, e.g. when CreateURL is
+// called. See ExpiresAt.
+func ExpiresIn(d time.Duration) IxParam {
+	return func(u *url.Values) {
+		ExpiresAt(time.Now().Add(d))(u)
+	}
+}
+
+// Without returns an IxParam that removes the given keys from the
+// builder's default params (see WithDefaultParams) for a single call.
+func Without(keys ...string) IxParam {
+	return func(u *url.Values) {
+		for _, k := range keys {
+			(*u)[k] = []string{}
+		}
+	}
+}
+
 // CreateURL creates a URL string given a path and a set of
 // params.
+//
+// If the params cannot be applied, e.g. if a param references an unknown
+// preset, fails strict param validation (see WithStrictParams), or
+// conflicts with an alias (see WithAliasNormalization), CreateURL returns
+// an empty string rather than a URL without them; use CreateURLE to find
+// out why.
 func (b *URLBuilder) CreateURL(path string, params ...IxParam) string {
+	url, _ := b.CreateURLE(path, params...)
+	return url
+}
+
+// CreateURLE functions like CreateURL except that it returns an error if
+// the params cannot be applied, e.g. if a param references an unknown
+// preset, fails strict param validation, has no base64 variant but was
+// given to Base64Param, or conflicts with another param of the same name
+// (see WithAliasNormalization).
+func (b *URLBuilder) CreateURLE(path string, params ...IxParam) (string, error) {
+	urlParams, _, err := b.buildValues(params)
+	if err != nil {
+		return "", err
+	}
+	return b.createURLFromValues(path, urlParams), nil
+}
+
+// layerInfo describes how the params built by buildValues were layered.
+type layerInfo struct {
+	options        []SrcsetOption // The srcset options of the referenced presets.
+	defaultQuality bool           // Whether q was set by the default params alone.
+}
+
+// buildValues applies the builder's default params, then the params of
+// any presets referenced by Preset, and then the given params. Each key
+// set by a later layer replaces that key's values from the earlier ones,
+// and keys left without values (see Without) are removed. Presets
+// referenced by the default params apply beneath them in the same way.
+// The srcset options of the referenced presets are returned along with
+// the values. If the builder promotes params to their base64 variants,
+// that happens first. Then, if the builder validates params strictly, the
+// resulting values are validated. Params equal to their defaults are kept
+// until each URL is built (see withoutDefaults), so that srcsets can tell
+// an explicit default, e.g. q=75, from an absent param.
+func (b *URLBuilder) buildValues(params []IxParam) (url.Values, layerInfo, error) {
 	urlParams := url.Values{}
+	for _, fn := range b.defaultParams {
+		fn(&urlParams)
+	}
 
+	callParams := url.Values{}
 	for _, fn := range params {
-		fn(&urlParams)
+		fn(&callParams)
+	}
+
+	if err := b.normalizeLayer(urlParams); err != nil {
+		return nil, layerInfo{}, err
 	}
 
-	scheme := b.Scheme()
-	domain := b.Domain()
-	path = sanitizePath(path)
-	query := b.buildQueryString(urlParams)
-	signature := b.sign(path, query)
+	if err := b.normalizeLayer(callParams); err != nil {
+		return nil, layerInfo{}, err
+	}
 
-	url := scheme + "://" + domain + path
+	defaultPresetParams, defaultOptions, err := b.applyPresets(urlParams)
+	if err != nil {
+		return nil, layerInfo{}, err
+	}
 
-	// If the query and signature are empty, return the url.
-	if query == "" && signature == "" {
-		return url
+	for k, v := range defaultPresetParams {
+		if _, ok := urlParams[k]; !ok {
+			urlParams[k] = v
+		}
 	}
 
-	// If the signature is empty, but the query is not,
-	// return the url with the query appended.
-	if query != "" && signature == "" {
-		return url + "?" + query
+	presetParams, options, err := b.applyPresets(callParams)
+	if err != nil {
+		return nil, layerInfo{}, err
 	}
 
-	// If the query is empty, but the signature is not,
-	// return the url with the signature appended.
-	if query == "" && signature != "" {
-		return url + "?" + signature
+	_, presetQuality := presetParams["q"]
+	_, callQuality := callParams["q"]
+	info := layerInfo{
+		options:        append(defaultOptions, options...),
+		defaultQuality: urlParams.Get("q") != "" && !presetQuality && !callQuality}
+
+	for _, layer := range []url.Values{presetParams, callParams} {
+		for k, v := range layer {
+			urlParams[k] = v
+		}
 	}
 
-	// If neither query nor signature is empty, append the
-	// query, then append the signature.
-	if query != "" && signature != "" {
-		url += "?" + query + "&" + signature
+	for k, v := range urlParams {
+		if len(v) == 0 {
+			delete(urlParams, k)
+		}
 	}
 
-	return url
+	if err := takeNoBase64(urlParams); err != nil {
+		return nil, layerInfo{}, err
+	}
+
+	if b.promote64 {
+		promoteBase64(urlParams)
+	}
+
+	if b.strictParams {
+		if err := validateParams(urlParams); err != nil {
+			return nil, layerInfo{}, err
+		}
+	}
+	return urlParams, info, nil
+}
+
+// normalizeLayer replaces the aliases in a layer of params with their
+// canonical keys, if the builder normalizes aliases.
+func (b *URLBuilder) normalizeLayer(params url.Values) error {
+	if !b.normalize {
+		return nil
+	}
+	return normalizeAliases(params)
 }
 
 // createURLFromValues functions like CreateURL except that
 // it accepts url.Values.
 func (b *URLBuilder) createURLFromValues(path string, params url.Values) string {
-	scheme := b.Scheme()
-	domain := b.Domain()
-	path = sanitizePath(path)
-	query := b.buildQueryString(params)
-	signature := b.sign(path, query)
+	buf := getBuffer()
+	defer putBuffer(buf)
 
-	url := scheme + "://" + domain + path
+	pathBuf := getBuffer()
+	defer putBuffer(pathBuf)
+
+	*pathBuf = b.appendPath((*pathBuf)[:0], path)
+	*buf = b.appendURL((*buf)[:0], *pathBuf, b.withoutDefaults(params))
+	return string(*buf)
+}
 
-	// If the query and signature are empty, return the url.
-	if query == "" && signature == "" {
-		return url
+// appendPath appends the builder's path prefix and the sanitized form of
+// the path to dst.
+func (b *URLBuilder) appendPath(dst []byte, path string) []byte {
+	dst = append(dst, b.pathPrefix...)
+	return appendSanitizedPath(dst, path)
+}
+
+// appendURL appends a URL with the given (sanitized) path and params to
+// dst. The URL is built directly in dst, and the path and query are
+// signed from there, so that no intermediate strings are created.
+func (b *URLBuilder) appendURL(dst []byte, path []byte, params url.Values) []byte {
+	shard := b.pickShard(path)
+
+	dst = append(dst, b.Scheme()...)
+	dst = append(dst, "://"...)
+	dst = append(dst, shard.domain...)
+
+	pathStart := len(dst)
+	dst = append(dst, path...)
+	pathEnd := len(dst)
+
+	if b.useLibParam {
+		params["ixlib"] = ixLibValues
 	}
 
-	// If the signature is empty, but the query is not,
-	// return the url with the query appended.
-	if query != "" && signature == "" {
-		return url + "?" + query
+	// Sort the keys in an array on the stack, unless there are many.
+	var keyArray [16]string
+	dst = append(dst, '?')
+	dst, _ = appendEncodedQuery(dst, params, keyArray[:0])
+
+	hasQuery := len(dst) > pathEnd+1
+	if !hasQuery {
+		dst = dst[:pathEnd]
 	}
 
-	// If the query is empty, but the signature is not,
-	// return the url with the signature appended.
-	if query == "" && signature != "" {
-		return url + "?" + signature
+	signer := b.signerFor(shard)
+	if signer == nil {
+		return dst
 	}
 
-	// If neither query nor signature is empty, append the
-	// query, then append the signature.
-	if query != "" && signature != "" {
-		url += "?" + query + "&" + signature
+	if hasQuery {
+		dst = append(dst, '&')
+	} else {
+		dst = append(dst, '?')
 	}
 
-	return url
+	// The query is everything between the '?' and the '&' just appended.
+	var query []byte
+	if hasQuery {
+		query = dst[pathEnd+1 : len(dst)-1]
+	}
+
+	dst = append(dst, "s="...)
+	return appendSignature(dst, signer, dst[pathStart:pathEnd], query)
 }
 
-func (b *URLBuilder) buildQueryString(params url.Values) string {
-	var encodedQueryParts []string
-	if b.useLibParam {
-		params.Set("ixlib", ixLibVersion)
-	}
-	encodedQueryParts = encodeQuery(params)
-	return strings.Join(encodedQueryParts, "&")
+// ixLibValues holds the value of the ixlib param. It is shared by every
+// URL, and so it must not be modified.
+var ixLibValues = []string{ixLibVersion}
+
+// bufferPool pools the buffers that URLs and srcsets are built in.
+var bufferPool = sync.Pool{New: func() interface{} {
+	buf := make([]byte, 0, 1024)
+	return &buf
+}}
+
+// getBuffer gets an empty buffer from the bufferPool.
+func getBuffer() *[]byte {
+	buf := bufferPool.Get().(*[]byte)
+	*buf = (*buf)[:0]
+	return buf
 }
 
-func (b *URLBuilder) sign(path string, query string) string {
-	if b.token == "" {
-		return ""
+// putBuffer returns a buffer to the bufferPool. Very large buffers, e.g.
+// those used for long srcsets, are dropped rather than kept around.
+func putBuffer(buf *[]byte) {
+	const maxBufferSize = 64 << 10
+	if cap(*buf) > maxBufferSize {
+		return
 	}
-
-	signature := createMd5Signature(b.token, path, query)
-	return strings.Join([]string{"s=", signature}, "")
+	bufferPool.Put(buf)
 }
 
-// processPath processes a path string into a form that can be
+// sanitizePath processes a path string into a form that can be
 // safely used in a URL path segment.
 func sanitizePath(path string) string {
-	if path == "" {
-		return path
-	}
+	return string(appendSanitizedPath(nil, path))
+}
 
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
+// appendSanitizedPath appends the sanitized form of the path to dst.
+// See sanitizePath.
+func appendSanitizedPath(dst []byte, path string) []byte {
+	if path == "" {
+		return dst
 	}
 
 	isProxy, isEncoded := checkProxyStatus(path)
 
+	if isProxy && isEncoded {
+		if !strings.HasPrefix(path, "/") {
+			dst = append(dst, '/')
+		}
+		return append(dst, path...)
+	}
+
 	if isProxy {
-		return encodeProxy(path, isEncoded)
+		return append(dst, encodeProxy(path, isEncoded)...)
 	}
-	return encodePath(path)
+	return appendEncodedPath(dst, path)
 }
@@ -1 +1,161 @@
 package imgix
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestURLBuilder_With(t *testing.T) {
+	u := NewURLBuilder("test.imgix.net", WithLibParam(false))
+	derived := u.With(WithHTTPS(false), WithToken("FOO123bar"))
+
+	got := u.CreateURL("users/1.png")
+	want := "https://test.imgix.net/users/1.png"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	got = derived.CreateURL("users/1.png")
+	want = "http://test.imgix.net/users/1.png?s=6797c24146142d5b40bde3141fd3600c"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestURLBuilder_WithBaseURL(t *testing.T) {
+	u := NewURLBuilder("test.imgix.net", WithLibParam(false))
+	local := u.With(WithBaseURL("http://localhost:8080/imgix"))
+
+	got := local.CreateURL("image.png")
+	want := "http://localhost:8080/imgix/image.png"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// An empty base URL restores the builder's domain and scheme.
+	restored := local.With(WithBaseURL(""))
+	got = restored.CreateURL("image.png")
+	want = "https://test.imgix.net/image.png"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestURLBuilder_WithBaseURLOptionOrder(t *testing.T) {
+	orders := [][]BuilderOption{
+		{WithBaseURL("http://localhost:8080"), WithHTTPS(true)},
+		{WithHTTPS(true), WithBaseURL("http://localhost:8080")},
+	}
+
+	for _, options := range orders {
+		u := NewURLBuilder("test.imgix.net", append(options, WithLibParam(false))...)
+
+		got := u.CreateURL("image.png")
+		want := "http://localhost:8080/image.png"
+		if got != want {
+			t.Errorf("\ngot:  %s\nwant: %s", got, want)
+		}
+	}
+
+	// The builder's own scheme is kept while a base URL is in use.
+	u := NewURLBuilder("test.imgix.net", WithHTTPS(false), WithLibParam(false))
+	local := u.With(WithBaseURL("https://localhost:8443"))
+	if got := local.Scheme(); got != "https" {
+		t.Errorf("got: %s; want: https", got)
+	}
+
+	restored := local.With(WithBaseURL(""))
+	if got := restored.Scheme(); got != "http" {
+		t.Errorf("got: %s; want: http", got)
+	}
+}
+
+func TestURLBuilder_WithEInvalid(t *testing.T) {
+	u := NewURLBuilder("test.imgix.net/foo")
+
+	_, err := u.WithE(WithStrictDomain(true))
+	if !errors.Is(err, ErrDomainHasPath) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrDomainHasPath)
+	}
+
+	_, err = u.WithE(WithBaseURL("ftp://localhost"))
+	if !errors.Is(err, ErrInvalidBaseURL) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrInvalidBaseURL)
+	}
+}
+
+func TestURLBuilder_WithPresetDoesNotModifyBuilder(t *testing.T) {
+	u := NewURLBuilder("test.imgix.net",
+		WithLibParam(false),
+		WithPreset("thumb", []IxParam{Param("w", "100")}))
+	derived := u.With(WithPreset("thumb", []IxParam{Param("w", "200")}))
+
+	got := u.CreateURL("image.png", Preset("thumb"))
+	want := "https://test.imgix.net/image.png?w=100"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	got = derived.CreateURL("image.png", Preset("thumb"))
+	want = "https://test.imgix.net/image.png?w=200"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+// TestURLBuilder_Concurrent exercises a single builder from many goroutines.
+// Run it with the race detector (go test -race) to check for data races.
+func TestURLBuilder_Concurrent(t *testing.T) {
+	builders := []URLBuilder{
+		NewURLBuilder("test.imgix.net",
+			WithToken("FOO123bar"),
+			WithDefaultParams(Param("auto", "format")),
+			WithPreset("thumb", []IxParam{Param("w", "100")}, WithVariableQuality(false))),
+		NewShardedURLBuilder(
+			[]string{"a.imgix.net", "b.imgix.net"},
+			WithToken("FOO123bar"),
+			WithPreset("thumb", []IxParam{Param("w", "100")}),
+			WithShardStrategy(ShardRoundRobin)),
+	}
+
+	for _, u := range builders {
+		wantURL := u.CreateURL("image.png", Param("w", "100"))
+		wantSrcset := u.CreateSrcset("image.png", []IxParam{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				derived := u.With(WithHTTPS(false))
+
+				for j := 0; j < 50; j++ {
+					gotURL := u.CreateURL("image.png", Param("w", "100"))
+					if !u.sharded && gotURL != wantURL {
+						t.Errorf("\ngot:  %s\nwant: %s", gotURL, wantURL)
+					}
+
+					gotSrcset := u.CreateSrcset("image.png", []IxParam{})
+					if !u.sharded && gotSrcset != wantSrcset {
+						t.Errorf("\ngot: \n%s\n\nwant: \n%s", gotSrcset, wantSrcset)
+					}
+
+					u.CreateSrcset("image.png", []IxParam{Preset("thumb")})
+					u.CreateSrcsetFromWidths("image.png", []IxParam{}, []int{100, 200})
+
+					if err := u.Verify(gotURL); err != nil {
+						t.Errorf("got: err == %v; want: err == nil", err)
+					}
+
+					gotDerived := derived.CreateURL("image.png")
+					if !strings.HasPrefix(gotDerived, "http://") {
+						t.Errorf("got: %s; want: http://", gotDerived)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,462 @@
+// Command paramgen generates the typed param constructors, enum constants,
+// and validation tables of the imgix package from the vendored imgix
+// parameter spec (spec/parameters.json). It is run by go generate:
+//
+//	go generate github.com/imgix/imgix-go/v2
+//
+// Every param in the spec gets an entry in the validation tables, and the
+// enum constants track the spec's possible values, so updating the
+// vendored spec is enough to pick up new params and values. Constructors
+// are only generated for the params listed in constructors, since their
+// Go names are chosen by hand.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// constructor describes a typed IxParam constructor to generate.
+type constructor struct {
+	Key  string // The param's key in the spec, e.g. "w".
+	Name string // The constructor's name, e.g. "Width".
+	Enum string // The name of the param's enum type, if it has possible values.
+	Unit string // The unit of the param's values, e.g. "pixels", if any.
+	Note string // A note appended to the constructor's doc comment, if any.
+}
+
+// constructors lists the params that get typed constructors.
+var constructors = []constructor{
+	{Key: "w", Name: "Width", Unit: "pixels"},
+	{Key: "h", Name: "Height", Unit: "pixels"},
+	{Key: "fit", Name: "Fit", Enum: "FitMode"},
+	{Key: "crop", Name: "Crop", Enum: "CropMode",
+		Note: "Multiple modes are combined, e.g. Crop(CropFaces, CropEntropy) yields crop=faces,entropy."},
+	{Key: "fm", Name: "Format", Enum: "OutputFormat"},
+	{Key: "q", Name: "Quality"},
+	{Key: "dpr", Name: "DPR",
+		Note: "The ratio is formatted with as few digits as represent it exactly, e.g. 2 rather than 2.0 and 1.5 rather than 1.50."},
+	{Key: "auto", Name: "Auto", Enum: "AutoMode",
+		Note: "Multiple modes are combined, e.g. Auto(AutoFormat, AutoCompress) yields auto=format,compress."},
+}
+
+// unorderedLists are the list params whose items are flags or alignments,
+// so that their order does not change the image, e.g. auto=format,compress
+// and auto=compress,format. The items of other lists, e.g. the coordinates
+// of rect or the colors of duotone, are positional. The published spec
+// does not say which lists are which, so they are listed here.
+var unorderedLists = map[string]bool{
+	"auto":        true,
+	"blend-align": true,
+	"ch":          true,
+	"mark-align":  true,
+	"txt-align":   true,
+}
+
+// constNames overrides the suffixes of enum constant names for values that
+// are acronyms or compound words, e.g. FormatAVIF rather than FormatAvif.
+var constNames = map[string]string{
+	"avif":       "AVIF",
+	"blurhash":   "BlurHash",
+	"facearea":   "FaceArea",
+	"fillmax":    "FillMax",
+	"focalpoint": "FocalPoint",
+	"gif":        "GIF",
+	"jp2":        "JP2",
+	"jpg":        "JPG",
+	"json":       "JSON",
+	"jxr":        "JXR",
+	"mp4":        "MP4",
+	"pjpg":       "PJPG",
+	"png":        "PNG",
+	"png8":       "PNG8",
+	"png32":      "PNG32",
+	"redeye":     "RedEye",
+	"webm":       "WebM",
+	"webp":       "WebP",
+}
+
+// kinds maps the expectation types of the spec to the paramKind constants
+// of the imgix package.
+var kinds = map[string]string{
+	"color_keyword": "kindColorKeyword",
+	"hex_color":     "kindHexColor",
+	"integer":       "kindInteger",
+	"list":          "kindList",
+	"number":        "kindNumber",
+	"ratio":         "kindRatio",
+	"string":        "kindString",
+	"timestamp":     "kindTimestamp",
+	"unit_scalar":   "kindUnitScalar",
+	"url":           "kindURL",
+}
+
+// spec is the subset of the imgix parameter spec used by the generator.
+type spec struct {
+	Version    string               `json:"version"`
+	Parameters map[string]parameter `json:"parameters"`
+	Aliases    map[string]string    `json:"aliases"`
+}
+
+type parameter struct {
+	DisplayName      string        `json:"display_name"`
+	Expects          []expectation `json:"expects"`
+	Default          string        `json:"default"`
+	Depends          []string      `json:"depends"`
+	SupportsBase64   bool          `json:"supports_base64"`
+	ShortDescription string        `json:"short_description"`
+}
+
+type expectation struct {
+	Type           string   `json:"type"`
+	PossibleValues []string `json:"possible_values"`
+	StrictRange    *struct {
+		Min *float64 `json:"min"`
+		Max *float64 `json:"max"`
+	} `json:"strict_range"`
+}
+
+func main() {
+	specPath := flag.String("spec", "spec/parameters.json", "path to the imgix parameter spec")
+	outPath := flag.String("o", "params_gen.go", "path of the generated file")
+	flag.Parse()
+
+	specJSON, err := ioutil.ReadFile(*specPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	src, err := generate(specJSON)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := ioutil.WriteFile(*outPath, src, 0644); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// generate returns the gofmt-ed source of the generated file.
+func generate(specJSON []byte) ([]byte, error) {
+	var s spec
+	if err := json.Unmarshal(specJSON, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %v", err)
+	}
+
+	data, err := newTemplateData(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+type templateData struct {
+	Version      string
+	Constructors []constructorData
+	Params       []paramData
+	Aliases      []aliasData
+}
+
+type constructorData struct {
+	constructor
+	Key         string
+	DisplayName string
+	Doc         string // The constructor's doc comment, before wrapping.
+	ArgType     string // The Go type of the constructor's argument.
+	Format      string // The expression that formats the argument.
+	Variadic    bool
+	Values      []enumValue
+}
+
+type enumValue struct {
+	Name  string
+	Value string
+}
+
+type paramData struct {
+	Key       string
+	Expects   []string
+	Default   string
+	Depends   []string
+	Base64    bool
+	Unordered bool
+}
+
+type aliasData struct {
+	Alias     string
+	Canonical string
+}
+
+func newTemplateData(s spec) (templateData, error) {
+	if s.Version == "" {
+		return templateData{}, fmt.Errorf("spec has no version")
+	}
+	data := templateData{Version: s.Version}
+
+	for _, c := range constructors {
+		p, ok := s.Parameters[c.Key]
+		if !ok {
+			return data, fmt.Errorf("constructor %s: unknown param %q", c.Name, c.Key)
+		}
+		if len(p.Expects) == 0 {
+			return data, fmt.Errorf("constructor %s: param %q has no expectations", c.Name, c.Key)
+		}
+
+		e := p.Expects[0]
+		cd := constructorData{
+			constructor: c,
+			Key:         c.Key,
+			Doc:         constructorDoc(c, p, e),
+			DisplayName: p.DisplayName}
+
+		switch {
+		case c.Enum != "":
+			if len(e.PossibleValues) == 0 {
+				return data, fmt.Errorf("constructor %s: param %q has no possible values", c.Name, c.Key)
+			}
+			cd.ArgType = c.Enum
+			cd.Variadic = e.Type == "list"
+			for _, v := range e.PossibleValues {
+				cd.Values = append(cd.Values, enumValue{Name: c.Name + constName(v), Value: v})
+			}
+		case e.Type == "integer":
+			cd.ArgType = "int"
+			cd.Format = "strconv.Itoa(v)"
+		case e.Type == "number":
+			cd.ArgType = "float64"
+			cd.Format = "formatFloat(v)"
+		default:
+			return data, fmt.Errorf("constructor %s: unsupported type %q", c.Name, e.Type)
+		}
+		data.Constructors = append(data.Constructors, cd)
+	}
+
+	keys := make([]string, 0, len(s.Parameters))
+	for k := range s.Parameters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := s.Parameters[k]
+		pd := paramData{
+			Key:       k,
+			Default:   p.Default,
+			Depends:   p.Depends,
+			Base64:    p.SupportsBase64,
+			Unordered: unorderedLists[k]}
+
+		for _, e := range p.Expects {
+			expr, err := expectationExpr(e)
+			if err != nil {
+				return data, fmt.Errorf("param %q: %v", k, err)
+			}
+			pd.Expects = append(pd.Expects, expr)
+		}
+		data.Params = append(data.Params, pd)
+	}
+
+	aliases := make([]string, 0, len(s.Aliases))
+	for alias, canonical := range s.Aliases {
+		if _, ok := s.Parameters[canonical]; !ok {
+			return data, fmt.Errorf("alias %q: unknown param %q", alias, canonical)
+		}
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		data.Aliases = append(data.Aliases, aliasData{Alias: alias, Canonical: s.Aliases[alias]})
+	}
+	return data, nil
+}
+
+// constructorDoc returns the doc comment of a constructor: the param it
+// sets, the unit and range of its values, the spec's description, and the
+// constructor's note.
+func constructorDoc(c constructor, p parameter, e expectation) string {
+	doc := fmt.Sprintf("%s sets the %q param (%s)", c.Name, c.Key, p.DisplayName)
+	if c.Unit != "" {
+		doc += ", in " + c.Unit
+	}
+	doc += ". " + p.ShortDescription
+
+	if r := e.StrictRange; r != nil {
+		switch {
+		case r.Min != nil && r.Max != nil:
+			doc += fmt.Sprintf(" Values range from %s to %s.", formatFloat(*r.Min), formatFloat(*r.Max))
+		case r.Min != nil:
+			doc += fmt.Sprintf(" Values must be at least %s.", formatFloat(*r.Min))
+		case r.Max != nil:
+			doc += fmt.Sprintf(" Values must be at most %s.", formatFloat(*r.Max))
+		}
+	}
+
+	if c.Note != "" {
+		doc += " " + c.Note
+	}
+	return doc
+}
+
+// comment returns text as a Go comment, wrapped so that no line is longer
+// than 76 characters unless it holds a single long word.
+func comment(text string) string {
+	const width = 76
+
+	var lines []string
+	line := "//"
+	for _, word := range strings.Fields(text) {
+		if len(line)+1+len(word) > width && line != "//" {
+			lines = append(lines, line)
+			line = "//"
+		}
+		line += " " + word
+	}
+	return strings.Join(append(lines, line), "\n")
+}
+
+// expectationExpr returns the Go expression of a paramExpectation.
+func expectationExpr(e expectation) (string, error) {
+	kind, ok := kinds[e.Type]
+	if !ok {
+		return "", fmt.Errorf("unsupported type %q", e.Type)
+	}
+
+	fields := []string{"kind: " + kind}
+	if r := e.StrictRange; r != nil {
+		if r.Min != nil {
+			fields = append(fields, "min: "+formatFloat(*r.Min), "hasMin: true")
+		}
+		if r.Max != nil {
+			fields = append(fields, "max: "+formatFloat(*r.Max), "hasMax: true")
+		}
+	}
+	if len(e.PossibleValues) > 0 {
+		fields = append(fields, "values: "+stringSlice(e.PossibleValues))
+	}
+	return "{" + strings.Join(fields, ", ") + "}", nil
+}
+
+// constName returns the suffix of an enum constant's name, e.g. "Crop"
+// for "crop" and "PNG8" for "png8".
+func constName(value string) string {
+	if name, ok := constNames[value]; ok {
+		return name
+	}
+
+	var b strings.Builder
+	for _, part := range strings.Split(value, "-") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func stringSlice(values []string) string {
+	quoted := make([]string, len(values))
+	for idx, v := range values {
+		quoted[idx] = strconv.Quote(v)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+var fileTemplate = template.Must(template.New("params_gen.go").Funcs(template.FuncMap{
+	"comment":     comment,
+	"quote":       strconv.Quote,
+	"stringSlice": stringSlice,
+}).Parse(`// Code generated by paramgen from spec/parameters.json; DO NOT EDIT.
+
+package imgix
+
+import "strconv"
+
+// ParamSpecVersion is the version of the imgix parameter spec that the
+// typed param constructors and validation tables were generated from.
+const ParamSpecVersion = {{quote .Version}}
+{{range .Constructors}}{{if .Enum}}
+{{comment (printf "%s is a value of the %q param (%s). See %s." .Enum .Key .DisplayName .Name)}}
+type {{.Enum}} string
+
+// The documented values of the {{quote .Key}} param.
+const (
+{{- $enum := .Enum}}{{range .Values}}
+	{{.Name}} {{$enum}} = {{quote .Value}}
+{{- end}}
+)
+{{end}}{{end}}
+{{- range .Constructors}}
+{{comment .Doc}}
+{{- if .Variadic}}
+func {{.Name}}(v ...{{.ArgType}}) IxParam {
+	values := make([]string, len(v))
+	for idx, value := range v {
+		values[idx] = string(value)
+	}
+	return Param({{quote .Key}}, values...)
+}
+{{else if .Enum}}
+func {{.Name}}(v {{.ArgType}}) IxParam {
+	return Param({{quote .Key}}, string(v))
+}
+{{else}}
+func {{.Name}}(v {{.ArgType}}) IxParam {
+	return Param({{quote .Key}}, {{.Format}})
+}
+{{end}}{{end}}
+// paramSpecs describes every param in the imgix parameter spec, by key.
+var paramSpecs = map[string]paramSpec{
+{{- range .Params}}
+	{{quote .Key}}: {
+		expects: []paramExpectation{
+{{- range .Expects}}
+			{{.}},
+{{- end}}
+		},
+{{- if .Default}}
+		defaultValue: {{quote .Default}},
+{{- end}}
+{{- if .Depends}}
+		depends: {{stringSlice .Depends}},
+{{- end}}
+{{- if .Base64}}
+		base64: true,
+{{- end}}
+{{- if .Unordered}}
+		unordered: true,
+{{- end}}
+	},
+{{- end}}
+}
+
+// paramAliases maps the aliases of params to their canonical keys.
+var paramAliases = map[string]string{
+{{- range .Aliases}}
+	{{quote .Alias}}: {{quote .Canonical}},
+{{- end}}
+}
+`))
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestParamgen_upToDate(t *testing.T) {
+	specJSON, err := ioutil.ReadFile("../../spec/parameters.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ioutil.ReadFile("../../params_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := generate(specJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Error("params_gen.go is out of date; run go generate")
+	}
+}
+
+// withoutConstructors clears the constructors table, so that specs
+// without the params of the constructors can be generated. It returns a
+// func that restores the table.
+func withoutConstructors() func() {
+	saved := constructors
+	constructors = nil
+	return func() { constructors = saved }
+}
+
+func TestParamgen_unsupportedType(t *testing.T) {
+	const specJSON = `{
+		"version": "1.0.0",
+		"parameters": {"foo": {"expects": [{"type": "bar"}]}}
+	}`
+	defer withoutConstructors()()
+
+	_, err := generate([]byte(specJSON))
+	if err == nil || !strings.Contains(err.Error(), `unsupported type "bar"`) {
+		t.Errorf("\ngot:  %v\nwant: unsupported type \"bar\"", err)
+	}
+}
+
+func TestParamgen_unknownAlias(t *testing.T) {
+	const specJSON = `{
+		"version": "1.0.0",
+		"parameters": {},
+		"aliases": {"width": "w"}
+	}`
+	defer withoutConstructors()()
+
+	_, err := generate([]byte(specJSON))
+	if err == nil || !strings.Contains(err.Error(), `alias "width": unknown param "w"`) {
+		t.Errorf("\ngot:  %v\nwant: alias \"width\": unknown param \"w\"", err)
+	}
+}
+
+func TestParamgen_constName(t *testing.T) {
+	tests := map[string]string{
+		"crop":       "Crop",
+		"focalpoint": "FocalPoint",
+		"png8":       "PNG8",
+		"top-left":   "TopLeft",
+	}
+
+	for value, want := range tests {
+		got := constName(value)
+		if got != want {
+			t.Errorf("\ngot:  %s\nwant: %s", got, want)
+		}
+	}
+}
+
+func TestParamgen_constructorDoc(t *testing.T) {
+	min, max := 0.0, 100.0
+	e := expectation{Type: "integer"}
+	e.StrictRange = &struct {
+		Min *float64 `json:"min"`
+		Max *float64 `json:"max"`
+	}{&min, &max}
+	p := parameter{DisplayName: "output quality", ShortDescription: "Adjusts the quality of an output image."}
+
+	got := comment(constructorDoc(constructor{Key: "q", Name: "Quality", Unit: "percent"}, p, e))
+	want := "// Quality sets the \"q\" param (output quality), in percent. Adjusts the\n" +
+		"// quality of an output image. Values range from 0 to 100."
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	for _, line := range strings.Split(got, "\n") {
+		if len(line) > 76 {
+			t.Errorf("line is %d characters long: %s", len(line), line)
+		}
+	}
+}
+
+func TestParamgen_missingVersion(t *testing.T) {
+	const specJSON = `{"parameters": {}}`
+	defer withoutConstructors()()
+
+	_, err := generate([]byte(specJSON))
+	if err == nil || !strings.Contains(err.Error(), "spec has no version") {
+		t.Errorf("\ngot:  %v\nwant: spec has no version", err)
+	}
+}
@@ -0,0 +1,215 @@
+package imgix
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// LintRule identifies the check that produced a LintWarning.
+type LintRule string
+
+// The rules checked by Lint.
+const (
+	// LintMissingDependency flags a param that has no effect without
+	// another param, e.g. crop without fit=crop or dpr without w or h.
+	LintMissingDependency LintRule = "missing-dependency"
+
+	// LintConflictingSize flags ar given along with both w and h, in which
+	// case the aspect ratio is ignored.
+	LintConflictingSize LintRule = "conflicting-size"
+
+	// LintConflictingAlias flags an alias and its canonical key given with
+	// different values, e.g. orient=6 and or=8.
+	LintConflictingAlias LintRule = "conflicting-alias"
+
+	// LintDefaultValue flags a param whose value equals the default imgix
+	// uses when it is absent, e.g. fit=clip. See WithDropDefaults.
+	LintDefaultValue LintRule = "default-value"
+
+	// LintPathHasQuery flags a path containing '?' or '#', which are
+	// escaped as part of the path rather than starting a query.
+	LintPathHasQuery LintRule = "path-has-query"
+
+	// LintUncheckedPreset flags params given with Preset, which are
+	// registered on a URLBuilder and so cannot be checked by Lint.
+	LintUncheckedPreset LintRule = "unchecked-preset"
+)
+
+// LintWarning describes a param, or combination of params, that is
+// ineffective or conflicting.
+type LintWarning struct {
+	Rule    LintRule // The rule that produced the warning.
+	Message string   // A description of the problem.
+	Keys    []string // The keys of the offending params, if any.
+}
+
+func (w LintWarning) String() string {
+	return string(w.Rule) + ": " + w.Message
+}
+
+// Lint checks a path and params for combinations that have no effect or
+// that conflict, e.g. crop without fit=crop, fp-x without crop=focalpoint,
+// dpr without w or h, or ar along with both w and h. The dependencies
+// between params are taken from the imgix parameter spec, and aliases are
+// checked as their canonical keys. A warning does not mean that imgix will
+// reject the URL, only that it probably does not do what was intended.
+//
+// Presets are not expanded, since they are registered on a URLBuilder
+// rather than known to Lint. If any are referenced with Preset, a
+// LintUncheckedPreset warning names them, and only the params given
+// directly are checked; use LintURL on the URL built with the presets to
+// check their params too.
+//
+// A warning about the path comes first, then one about presets, and the
+// rest are sorted by the first offending key.
+func Lint(path string, params ...IxParam) []LintWarning {
+	values := url.Values{}
+	for _, fn := range params {
+		fn(&values)
+	}
+	presets := values[presetKey]
+	delete(values, presetKey)
+	delete(values, noBase64Key)
+
+	var warnings []LintWarning
+	if strings.ContainsAny(path, "?#") {
+		warnings = append(warnings, LintWarning{
+			Rule:    LintPathHasQuery,
+			Message: "path contains '?' or '#', which is escaped as part of the path; pass params as IxParams instead"})
+	}
+	if len(presets) > 0 {
+		warnings = append(warnings, LintWarning{
+			Rule:    LintUncheckedPreset,
+			Message: "the params of preset `" + strings.Join(presets, "`, `") + "` are not checked"})
+	}
+	return append(warnings, lintValues(values)...)
+}
+
+// LintURL functions like Lint except that it checks the path and params of
+// an existing URL. The ixlib and signature params are ignored. The
+// returned error matches ErrInvalidURL if the URL cannot be parsed.
+func LintURL(rawURL string) ([]LintWarning, error) {
+	p, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	delete(p.Params, "ixlib")
+	return lintValues(p.Params), nil
+}
+
+// lintValues checks params for ineffective and conflicting combinations.
+func lintValues(params url.Values) []LintWarning {
+	var warnings []LintWarning
+
+	// Check aliases first, so that the remaining checks can be made
+	// against canonical keys. The canonical key's values win over those
+	// of its aliases.
+	groups := make(map[string][]string)
+	for _, k := range sortedKeys(params) {
+		key := canonicalKey(k)
+		groups[key] = append(groups[key], k)
+	}
+
+	canonical := make(url.Values, len(groups))
+	for key, keys := range groups {
+		source := keys[0]
+		for _, k := range keys {
+			if k == key {
+				source = k
+			}
+		}
+		canonical[key] = params[source]
+
+		want := strings.Join(params[source], ",")
+		for _, k := range keys {
+			value := strings.Join(params[k], ",")
+			if value != want {
+				warnings = append(warnings, LintWarning{
+					Rule:    LintConflictingAlias,
+					Message: "`" + k + "=" + value + "` conflicts with `" + source + "=" + want + "`",
+					Keys:    []string{key, k}})
+			}
+		}
+	}
+
+	for _, k := range sortedKeys(canonical) {
+		spec, ok := specOf(k)
+		if !ok {
+			continue
+		}
+
+		for _, dep := range spec.depends {
+			if !dependencyMet(canonical, dep) {
+				alternatives := strings.Split(dep, "|")
+				keys := []string{k}
+				for _, alt := range alternatives {
+					keys = append(keys, strings.SplitN(alt, "=", 2)[0])
+				}
+
+				warnings = append(warnings, LintWarning{
+					Rule:    LintMissingDependency,
+					Message: "`" + k + "` has no effect without `" + strings.Join(alternatives, "` or `") + "`",
+					Keys:    keys})
+			}
+		}
+
+		value := strings.Join(canonicalValues(k, canonical[k]), ",")
+		if spec.defaultValue != "" && value == strings.Join(canonicalValues(k, []string{spec.defaultValue}), ",") {
+			warnings = append(warnings, LintWarning{
+				Rule:    LintDefaultValue,
+				Message: "`" + k + "=" + value + "` is the default and can be omitted",
+				Keys:    []string{k}})
+		}
+
+		if k == "ar" && canonical.Get("w") != "" && canonical.Get("h") != "" {
+			warnings = append(warnings, LintWarning{
+				Rule:    LintConflictingSize,
+				Message: "`ar` has no effect when both `w` and `h` are given",
+				Keys:    []string{"ar", "w", "h"}})
+		}
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Keys[0] < warnings[j].Keys[0]
+	})
+	return warnings
+}
+
+// dependencyMet reports whether the params meet a dependency from the
+// spec. A dependency is either a key, e.g. "blend", which is met by the
+// key or its base64 variant, or a key=value pair, e.g. "fit=crop", which
+// is met if the key's values include the value. Alternatives are
+// separated by '|', e.g. "w|h".
+func dependencyMet(params url.Values, dependency string) bool {
+	for _, alt := range strings.Split(dependency, "|") {
+		parts := strings.SplitN(alt, "=", 2)
+		key := parts[0]
+
+		if len(parts) == 1 {
+			if params.Get(key) != "" || params.Get(key+"64") != "" {
+				return true
+			}
+			continue
+		}
+
+		for _, v := range params[key] {
+			for _, item := range strings.Split(v, ",") {
+				if item == parts[1] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of params in increasing order.
+func sortedKeys(params url.Values) []string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,137 @@
+package imgix
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// lintRules returns the rules and first keys of warnings, for comparison.
+func lintRules(warnings []LintWarning) string {
+	var rules []string
+	for _, w := range warnings {
+		rule := string(w.Rule)
+		if len(w.Keys) > 0 {
+			rule += "(" + strings.Join(w.Keys, ",") + ")"
+		}
+		rules = append(rules, rule)
+	}
+	return strings.Join(rules, " ")
+}
+
+func TestLint_Lint(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		params []IxParam
+		want   string
+	}{
+		{
+			"clean",
+			"image.png",
+			[]IxParam{Width(320), Fit(FitCrop), Crop(CropFaces)},
+			"",
+		},
+		{
+			"crop without fit=crop",
+			"image.png",
+			[]IxParam{Width(320), Crop(CropFaces)},
+			"missing-dependency(crop,fit)",
+		},
+		{
+			"fp-x without crop=focalpoint",
+			"image.png",
+			[]IxParam{Width(320), Fit(FitCrop), Param("fp-x", "0.2")},
+			"missing-dependency(fp-x,crop)",
+		},
+		{
+			"dpr without w or h",
+			"image.png",
+			[]IxParam{DPR(2)},
+			"missing-dependency(dpr,w,h)",
+		},
+		{
+			"ar with w and h",
+			"image.png",
+			[]IxParam{Param("ar", "16:9"), Fit(FitCrop), Width(320), Height(240)},
+			"conflicting-size(ar,w,h)",
+		},
+		{
+			"default value",
+			"image.png",
+			[]IxParam{Width(320), Fit(FitClip)},
+			"default-value(fit)",
+		},
+		{
+			"no base64 variant",
+			"image.png",
+			[]IxParam{Width(320), Param("fit64", "clip")},
+			"",
+		},
+		{
+			"base64 dependency",
+			"image.png",
+			[]IxParam{Param("txt64", "Hello"), Param("txtclr", "FFF")},
+			"",
+		},
+		{
+			"conflicting alias",
+			"image.png",
+			[]IxParam{Param("orient", "6"), Param("or", "8")},
+			"conflicting-alias(or,orient)",
+		},
+		{
+			"path with query",
+			"image.png?w=320",
+			[]IxParam{},
+			"path-has-query",
+		},
+		{
+			"preset",
+			"image.png?w=320",
+			[]IxParam{Preset("thumb"), Preset("sharp"), Crop(CropFaces)},
+			"path-has-query unchecked-preset missing-dependency(crop,fit)",
+		},
+	}
+
+	for _, tt := range tests {
+		got := lintRules(Lint(tt.path, tt.params...))
+		if got != tt.want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLint_LintWarningString(t *testing.T) {
+	warnings := Lint("image.png", DPR(2))
+	if len(warnings) != 1 {
+		t.Fatalf("\ngot:  %v\nwant: 1 warning", warnings)
+	}
+
+	got := warnings[0].String()
+	want := "missing-dependency: `dpr` has no effect without `w` or `h`"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestLint_LintURL(t *testing.T) {
+	ub := NewURLBuilder("test.imgix.net", WithToken("FOO123bar"))
+	rawURL := ub.CreateURL("image.png", Crop(CropFaces), Param("fp-z", "2"))
+
+	warnings, err := LintURL(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := lintRules(warnings)
+	want := "missing-dependency(crop,fit) missing-dependency(fp-z,fit) missing-dependency(fp-z,crop)"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	_, err = LintURL("/image.png")
+	if !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrInvalidURL)
+	}
+}
@@ -0,0 +1,217 @@
+package imgix
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The typed param constructors, their enum types, and the paramSpecs and
+// paramAliases tables are generated from the vendored imgix parameter spec
+// (see spec/README.md and ParamSpecVersion).
+//go:generate go run ./internal/paramgen -spec spec/parameters.json -o params_gen.go
+
+// paramKind is the type of value that a param expects.
+type paramKind int
+
+const (
+	kindString       paramKind = iota // Any string.
+	kindInteger                       // A whole number, e.g. 320.
+	kindNumber                        // A decimal number, e.g. 1.5.
+	kindUnitScalar                    // A decimal number from 0 to 1.
+	kindList                          // A comma-separated list of values.
+	kindHexColor                      // A 3, 4, 6, or 8 digit hex color.
+	kindColorKeyword                  // A CSS color keyword, e.g. "red".
+	kindRatio                         // An aspect ratio, e.g. "16:9".
+	kindURL                           // A URL or path.
+	kindTimestamp                     // A UNIX timestamp.
+)
+
+// paramExpectation describes one form of value a param accepts. A param
+// with several expectations accepts a value matching any one of them.
+type paramExpectation struct {
+	kind   paramKind
+	min    float64  // The minimum of a number, if hasMin is set.
+	max    float64  // The maximum of a number, if hasMax is set.
+	hasMin bool     // Whether the value is bounded below.
+	hasMax bool     // Whether the value is bounded above.
+	values []string // The possible values, if the value is an enum.
+}
+
+// paramSpec describes a rendering param, as documented in the imgix
+// parameter spec.
+type paramSpec struct {
+	expects      []paramExpectation
+	defaultValue string   // The value imgix uses if the param is absent.
+	depends      []string // The params (or key=value pairs) the param requires; see Lint.
+	base64       bool     // Whether the param has a base64 ("64") variant.
+	unordered    bool     // Whether the order of the items of a list is insignificant.
+}
+
+// formatFloat formats a number param value in decimal notation, using the
+// fewest digits that represent it exactly.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// canonicalKey returns the canonical key of a param, which may be an
+// alias. The base64 variant of an alias, e.g. "txtfont64", maps to the
+// base64 variant of its canonical key, "txt-font64". Keys that are not
+// aliases are returned unchanged.
+func canonicalKey(key string) string {
+	if canonical, ok := paramAliases[key]; ok {
+		return canonical
+	}
+
+	if base := strings.TrimSuffix(key, "64"); base != key {
+		if canonical, ok := paramAliases[base]; ok && paramSpecs[canonical].base64 {
+			return canonical + "64"
+		}
+	}
+	return key
+}
+
+// specOf returns the spec of the param with the given canonical key. The
+// base64 variant of a param, e.g. "txt64", has the spec of its param, but
+// a key like "w64", which names no base64 variant, has none.
+func specOf(key string) (paramSpec, bool) {
+	if isBase64(key) {
+		key = strings.TrimSuffix(key, "64")
+	}
+	spec, ok := paramSpecs[key]
+	return spec, ok
+}
+
+// normalizeAliases replaces the aliases in params with their canonical
+// keys. If an alias and its canonical key are both present, their values
+// must be the same; otherwise the canonical key's values are kept, and an
+// error matching ErrConflictingAlias is returned once every alias has been
+// replaced.
+func normalizeAliases(params url.Values) error {
+	var err error
+	var aliases []string
+	for k := range params {
+		if canonicalKey(k) != k {
+			aliases = append(aliases, k)
+		}
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		canonical := canonicalKey(alias)
+		values := params[alias]
+		delete(params, alias)
+
+		existing, ok := params[canonical]
+		if !ok {
+			params[canonical] = values
+			continue
+		}
+
+		v, e := strings.Join(values, ","), strings.Join(existing, ",")
+		if v != e && err == nil {
+			err = fmt.Errorf("%w: `%s=%s` conflicts with `%s=%s`",
+				ErrConflictingAlias, alias, v, canonical, e)
+		}
+	}
+	return err
+}
+
+// ParamDefaults returns the values imgix uses for params that are absent,
+// as documented in the imgix parameter spec, by key. The returned map is a
+// copy, and may be modified freely. See WithDropDefaults.
+func ParamDefaults() map[string]string {
+	defaults := make(map[string]string)
+	for k, spec := range paramSpecs {
+		if spec.defaultValue != "" {
+			defaults[k] = spec.defaultValue
+		}
+	}
+	return defaults
+}
+
+// paramDefault returns the default value of a param, which may be an
+// alias. Defaults registered with WithParamDefault take precedence over
+// the documented ones.
+func (b *URLBuilder) paramDefault(key string) (string, bool) {
+	if value, ok := b.paramDefaults[key]; ok {
+		return value, true
+	}
+
+	key = canonicalKey(key)
+	if value, ok := b.paramDefaults[key]; ok {
+		return value, true
+	}
+
+	spec, ok := paramSpecs[key]
+	return spec.defaultValue, ok && spec.defaultValue != ""
+}
+
+// withoutDefaults returns the params without those whose values equal
+// their defaults, if the builder drops defaults. The params are copied
+// before any are removed, since the srcset builders reuse them for every
+// image candidate.
+func (b *URLBuilder) withoutDefaults(params url.Values) url.Values {
+	if !b.dropDefaults {
+		return params
+	}
+
+	var dropped url.Values
+	for k, values := range params {
+		if !b.isDefaultValue(k, values) {
+			continue
+		}
+
+		if dropped == nil {
+			dropped = make(url.Values, len(params))
+			for k, v := range params {
+				dropped[k] = v
+			}
+		}
+		delete(dropped, k)
+	}
+
+	if dropped == nil {
+		return params
+	}
+	return dropped
+}
+
+// isDefaultValue reports whether the values of a param equal its default.
+// Values are compared in their canonical form, so that numbers are
+// compared by value and unordered lists, e.g. auto, regardless of order
+// (see Canonicalize).
+func (b *URLBuilder) isDefaultValue(key string, values []string) bool {
+	defaultValue, ok := b.paramDefault(key)
+	if !ok {
+		return false
+	}
+
+	key = canonicalKey(key)
+	value := strings.Join(canonicalValues(key, values), ",")
+	return value == strings.Join(canonicalValues(key, []string{defaultValue}), ",")
+}
+
+// promoteBase64 replaces the params that have base64 variants with those
+// variants, if their values need base64 encoding (see needsBase64).
+func promoteBase64(params url.Values) {
+	for k, values := range params {
+		if isBase64(k) || !hasBase64Variant(k) {
+			continue
+		}
+
+		if _, ok := params[k+"64"]; ok {
+			continue
+		}
+
+		for _, v := range values {
+			if needsBase64(v) {
+				delete(params, k)
+				params[k+"64"] = values
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package imgix
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestParams_typedConstructors(t *testing.T) {
+	ub := testClient()
+
+	got := ub.CreateURL("image.jpg",
+		Width(320),
+		Height(240),
+		Fit(FitCrop),
+		Crop(CropFaces, CropEntropy),
+		Format(FormatAVIF),
+		Quality(75),
+		DPR(2),
+		Auto(AutoFormat, AutoCompress))
+	want := "https://test.imgix.net/image.jpg?auto=format%2Ccompress&crop=faces%2Centropy&dpr=2&fit=crop&fm=avif&h=240&q=75&w=320"
+
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestParams_matchesParam(t *testing.T) {
+	ub := testClient()
+
+	got := ub.CreateURL("image.jpg", Crop(CropTop, CropLeft), Auto(AutoCompress))
+	want := ub.CreateURL("image.jpg", Param("crop", "top", "left"), Param("auto", "compress"))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestParams_DPRFormatting(t *testing.T) {
+	tests := []struct {
+		ratio float64
+		want  string
+	}{
+		{1, "1"},
+		{2.0, "2"},
+		{1.5, "1.5"},
+		{2.25, "2.25"},
+		{0.1, "0.1"},
+		{1e21, "1000000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		values := url.Values{}
+		DPR(tt.ratio)(&values)
+
+		got := values.Get("dpr")
+		if got != tt.want {
+			t.Errorf("\ngot:  %s\nwant: %s", got, tt.want)
+		}
+	}
+}
+
+func TestParams_generatedTables(t *testing.T) {
+	if ParamSpecVersion == "" {
+		t.Error("ParamSpecVersion must not be empty")
+	}
+
+	for alias, canonical := range paramAliases {
+		if _, ok := paramSpecs[canonical]; !ok {
+			t.Errorf("alias %q refers to unknown param %q", alias, canonical)
+		}
+	}
+
+	fit := paramSpecs["fit"]
+	if fit.defaultValue != string(FitClip) {
+		t.Errorf("\ngot:  %s\nwant: %s", fit.defaultValue, FitClip)
+	}
+
+	q := paramSpecs["q"].expects[0]
+	if !q.hasMin || q.min != 0 || !q.hasMax || q.max != 100 {
+		t.Errorf("\ngot:  %+v\nwant: a range from 0 to 100", q)
+	}
+}
+
+func TestParams_normalizeAliases(t *testing.T) {
+	params := url.Values{
+		"orient":     []string{"6"},
+		"markalign":  []string{"top", "left"},
+		"txtfont64":  []string{"Avenir"},
+		"bm":         []string{"multiply"},
+		"blend-mode": []string{"multiply"},
+		"w":          []string{"320"},
+	}
+
+	if err := normalizeAliases(params); err != nil {
+		t.Fatal(err)
+	}
+
+	want := url.Values{
+		"or":         []string{"6"},
+		"mark-align": []string{"top", "left"},
+		"txt-font64": []string{"Avenir"},
+		"blend-mode": []string{"multiply"},
+		"w":          []string{"320"},
+	}
+
+	if params.Encode() != want.Encode() {
+		t.Errorf("\ngot:  %s\nwant: %s", params.Encode(), want.Encode())
+	}
+}
+
+func TestParams_normalizeAliasesConflict(t *testing.T) {
+	params := url.Values{
+		"orient": []string{"6"},
+		"or":     []string{"8"},
+	}
+
+	err := normalizeAliases(params)
+	if !errors.Is(err, ErrConflictingAlias) {
+		t.Fatalf("\ngot:  %v\nwant: %v", err, ErrConflictingAlias)
+	}
+
+	want := "imgix: conflicting alias: `orient=6` conflicts with `or=8`"
+	if err.Error() != want {
+		t.Errorf("\ngot:  %s\nwant: %s", err, want)
+	}
+}
+
+func TestParams_WithAliasNormalization(t *testing.T) {
+	ub := NewURLBuilder("test.imgix.net",
+		WithLibParam(false),
+		WithAliasNormalization(true),
+		WithDefaultParams(Param("orient", "6")))
+
+	got := ub.CreateURL("image.jpg", Param("txtclr", "FFF"))
+	want := "https://test.imgix.net/image.jpg?or=6&txt-color=FFF"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// A canonical key replaces an alias set by the defaults.
+	got = ub.CreateURL("image.jpg", Param("or", "8"))
+	want = "https://test.imgix.net/image.jpg?or=8"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	_, err := ub.CreateURLE("image.jpg", Param("bm", "screen"), Param("blend-mode", "overlay"))
+	if !errors.Is(err, ErrConflictingAlias) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrConflictingAlias)
+	}
+
+	// CreateURL builds no URL rather than picking one of the values.
+	got = ub.CreateURL("image.jpg", Param("bm", "screen"), Param("blend-mode", "overlay"))
+	if got != "" {
+		t.Errorf("\ngot:  %s\nwant: empty URL", got)
+	}
+
+	// Without normalization, aliases are left alone.
+	raw := ub.With(WithAliasNormalization(false))
+	got = raw.CreateURL("image.jpg", Param("txtclr", "FFF"))
+	want = "https://test.imgix.net/image.jpg?orient=6&txtclr=FFF"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestParams_WithDropDefaults(t *testing.T) {
+	ub := NewURLBuilder("test.imgix.net", WithLibParam(false), WithDropDefaults(true))
+
+	got := ub.CreateURL("image.jpg",
+		Param("dpr", "1.0"),
+		Param("fit", "clip"),
+		Param("q", "75"),
+		Param("orient", "0"),
+		Param("txtsize", "12"),
+		Param("w", "320"))
+	want := "https://test.imgix.net/image.jpg?orient=0&w=320"
+
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestParams_WithParamDefault(t *testing.T) {
+	ub := NewURLBuilder("test.imgix.net",
+		WithLibParam(false),
+		WithDropDefaults(true),
+		WithParamDefault("auto", "format,compress"),
+		WithParamDefault("q", "60"))
+
+	got := ub.CreateURL("image.jpg", Auto(AutoCompress, AutoFormat), Quality(75), DPR(1))
+	want := "https://test.imgix.net/image.jpg?q=75"
+
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// Defaults are only dropped when WithDropDefaults is enabled.
+	kept := ub.With(WithDropDefaults(false))
+	got = kept.CreateURL("image.jpg", DPR(1))
+	want = "https://test.imgix.net/image.jpg?dpr=1"
+
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestParams_ParamDefaults(t *testing.T) {
+	defaults := ParamDefaults()
+	if defaults["fit"] != "clip" || defaults["dpr"] != "1" {
+		t.Errorf("\ngot:  %v\nwant: fit=clip and dpr=1", defaults)
+	}
+
+	if _, ok := defaults["w"]; ok {
+		t.Errorf("w must not have a default")
+	}
+
+	// The returned map is a copy.
+	defaults["fit"] = "crop"
+	if ParamDefaults()["fit"] != "clip" {
+		t.Errorf("ParamDefaults must return a copy")
+	}
+}
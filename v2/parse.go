@@ -0,0 +1,124 @@
+package imgix
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ParsedURL holds the decoded parts of an imgix URL. See ParseURL.
+type ParsedURL struct {
+	Scheme    string     // Either "http" or "https".
+	Domain    string     // The URL's host, including its port, if any.
+	Path      string     // The decoded path, e.g. /users/1.png
+	Params    url.Values // The decoded query params, without the signature.
+	Signature string     // The value of the "s" param, if the URL is signed.
+}
+
+// ParseURL decomposes an imgix URL into its domain, decoded path, decoded
+// params, and signature. Web Proxy paths are decoded into their original
+// form (e.g. /http://avatars.com/john-smith.png) and the values of the
+// base64 variants of params (e.g. "txt64") are base64-decoded.
+//
+// ParseURL is the inverse of CreateURL: passing the Path and IxParams of
+// the result back to CreateURL, using a builder configured the same way
+// as the one that created the URL, yields the original URL. Note that a
+// path prefix given to WithBaseURL is part of the parsed Path.
+func ParseURL(rawURL string) (ParsedURL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ParsedURL{}, fmt.Errorf("%w %q: %v", ErrInvalidURL, rawURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ParsedURL{}, fmt.Errorf("%w %q: scheme must be http or https", ErrInvalidURL, rawURL)
+	}
+
+	if u.Host == "" {
+		return ParsedURL{}, fmt.Errorf("%w %q: host must not be empty", ErrInvalidURL, rawURL)
+	}
+
+	path, err := decodePath(u.EscapedPath())
+	if err != nil {
+		return ParsedURL{}, fmt.Errorf("%w %q: %v", ErrInvalidURL, rawURL, err)
+	}
+
+	params, err := decodeQuery(u.RawQuery)
+	if err != nil {
+		return ParsedURL{}, fmt.Errorf("%w %q: %v", ErrInvalidURL, rawURL, err)
+	}
+
+	signature := params.Get("s")
+	params.Del("s")
+
+	return ParsedURL{
+		Scheme:    u.Scheme,
+		Domain:    u.Host,
+		Path:      path,
+		Params:    params,
+		Signature: signature}, nil
+}
+
+// IxParams returns the parsed params as a slice of IxParam, so that they
+// can be passed back to CreateURL.
+func (p ParsedURL) IxParams() []IxParam {
+	keys := make([]string, 0, len(p.Params))
+	for k := range p.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	params := make([]IxParam, 0, len(keys))
+	for _, k := range keys {
+		params = append(params, Param(k, p.Params[k]...))
+	}
+	return params
+}
+
+// decodePath reverses sanitizePath. Web Proxy paths are unescaped as a
+// whole, while other paths are unescaped one component at a time.
+func decodePath(escapedPath string) (string, error) {
+	if escapedPath == "" {
+		return escapedPath, nil
+	}
+
+	isProxy, isEncoded := checkProxyStatus(escapedPath)
+	if isProxy && isEncoded {
+		return url.PathUnescape(escapedPath)
+	}
+
+	components := strings.Split(escapedPath, "/")
+	for idx, component := range components {
+		c, err := url.PathUnescape(component)
+		if err != nil {
+			return "", err
+		}
+		components[idx] = c
+	}
+	return strings.Join(components, "/"), nil
+}
+
+// decodeQuery reverses appendEncodedQuery. The values of base64 params are
+// base64-decoded.
+func decodeQuery(rawQuery string) (url.Values, error) {
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, values := range params {
+		if !isBase64(k) {
+			continue
+		}
+
+		for idx, v := range values {
+			decoded, err := base64DecodeQueryParamValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to base64-decode `%s` due to %w", k, err)
+			}
+			values[idx] = decoded
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,110 @@
+package imgix
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse_ParseURL(t *testing.T) {
+	got, err := ParseURL("https://test.imgix.net/users/1%2B2.png?auto=format%2Ccompress&txt64=SGVsbG8sIOS4lueVjA&w=400&s=abc123")
+	if err != nil {
+		t.Fatalf("got: err == %v; want: err == nil", err)
+	}
+
+	if got.Scheme != "https" {
+		t.Errorf("got: %s; want: https", got.Scheme)
+	}
+
+	if got.Domain != "test.imgix.net" {
+		t.Errorf("got: %s; want: test.imgix.net", got.Domain)
+	}
+
+	if got.Path != "/users/1+2.png" {
+		t.Errorf("got: %s; want: /users/1+2.png", got.Path)
+	}
+
+	if got.Signature != "abc123" {
+		t.Errorf("got: %s; want: abc123", got.Signature)
+	}
+
+	wantParams := map[string]string{
+		"auto":  "format,compress",
+		"txt64": "Hello, 世界",
+		"w":     "400",
+	}
+
+	if len(got.Params) != len(wantParams) {
+		t.Errorf("got: %v; want: %v", got.Params, wantParams)
+	}
+
+	for k, want := range wantParams {
+		if got.Params.Get(k) != want {
+			t.Errorf("%s\ngot:  %s\nwant: %s", k, got.Params.Get(k), want)
+		}
+	}
+}
+
+func TestParse_ParseURLProxy(t *testing.T) {
+	got, err := ParseURL("https://my-social-network.imgix.net/http%3A%2F%2Favatars.com%2Fjohn-smith.png")
+	if err != nil {
+		t.Fatalf("got: err == %v; want: err == nil", err)
+	}
+
+	const want = "/http://avatars.com/john-smith.png"
+	if got.Path != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got.Path, want)
+	}
+}
+
+func TestParse_ParseURLRoundTrip(t *testing.T) {
+	u := NewURLBuilder("my-social-network.imgix.net", WithToken("FOO123bar"))
+
+	cases := []struct {
+		path   string
+		params []IxParam
+	}{
+		{"", []IxParam{Param("auto", "format", "compress")}},
+		{"/", []IxParam{}},
+		{"users/1.png", []IxParam{Param("h", "300"), Param("w", "400")}},
+		{"E+P-003_D.jpeg", []IxParam{}},
+		{" <>[]{}|\\^%.jpg", []IxParam{}},
+		{"&$+,:;=?@#.jpg", []IxParam{Param("q", "50")}},
+		{"ساندویچ.jpg", []IxParam{}},
+		{"http://avatars.com/john-smith.png", []IxParam{Param("w", "400")}},
+		{"~text", []IxParam{Param("txt64", "I cannøt belîév∑ it wors! 😱")}},
+		{"image.png", []IxParam{Param("hello_world", "/foo\"> <script>alert(\"hacked\")</script><")}},
+	}
+
+	for _, c := range cases {
+		want := u.CreateURL(c.path, c.params...)
+
+		parsed, err := ParseURL(want)
+		if err != nil {
+			t.Errorf("%s\ngot: err == %v; want: err == nil", want, err)
+			continue
+		}
+
+		got := u.CreateURL(parsed.Path, parsed.IxParams()...)
+		if got != want {
+			t.Errorf("\ngot:  %s\nwant: %s", got, want)
+		}
+	}
+}
+
+func TestParse_ParseURLInvalid(t *testing.T) {
+	invalid := []string{
+		"test.imgix.net/image.png",
+		"ftp://test.imgix.net/image.png",
+		"https:///image.png",
+		"https://test.imgix.net/%zz.png",
+		"https://test.imgix.net/image.png?w=%zz",
+		"https://test.imgix.net/~text?txt64=not%20base64!",
+	}
+
+	for _, rawURL := range invalid {
+		_, err := ParseURL(rawURL)
+		if !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("%q\ngot:  %v\nwant: errors.Is(err, ErrInvalidURL)", rawURL, err)
+		}
+	}
+}
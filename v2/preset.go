@@ -0,0 +1,87 @@
+package imgix
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// presetKey is the url.Values key under which Preset records the names
+// of the presets to apply. It is removed before the query is encoded.
+const presetKey = "\x00preset"
+
+// preset is a named bundle of params and srcset options registered on a
+// builder with WithPreset.
+type preset struct {
+	params  []IxParam
+	options []SrcsetOption
+}
+
+// WithPreset returns a BuilderOption that NewURLBuilder consumes. It
+// registers a named bundle of params, and optionally srcset options (e.g.
+// a width range or tolerance), that can be applied to a URL or srcset by
+// passing Preset(name) along with the other params.
+func WithPreset(name string, params []IxParam, options ...SrcsetOption) BuilderOption {
+	return func(b *URLBuilder) {
+		presets := make(map[string]preset, len(b.presets)+1)
+		for n, p := range b.presets {
+			presets[n] = p
+		}
+		presets[name] = preset{params, options}
+		b.presets = presets
+	}
+}
+
+// Preset returns an IxParam that applies the params of the named preset
+// (see WithPreset). A preset's params replace the builder's default
+// params, and are replaced by the other params passed along with it, e.g.
+// CreateURL(path, Preset("thumb"), Param("q", "60")) uses q=60 whatever
+// the "thumb" preset's quality. A Preset passed to WithDefaultParams
+// applies in the same way to every URL, beneath the other default params.
+// Referencing a preset that has not been registered is an error: the E
+// variants, e.g. CreateURLE, return an error matching ErrUnknownPreset,
+// and the other Create methods return an empty result rather than a URL
+// without the preset's params.
+func Preset(name string) IxParam {
+	return func(u *url.Values) {
+		u.Add(presetKey, name)
+	}
+}
+
+// applyPresets removes the preset names recorded by Preset from a layer
+// of params. It returns the params of the named presets, each preset's
+// params replacing those of the presets before it, and their srcset
+// options. Unknown presets are skipped, and the first of them is reported
+// as an error matching ErrUnknownPreset along with the other presets.
+func (b *URLBuilder) applyPresets(layer url.Values) (url.Values, []SrcsetOption, error) {
+	names := layer[presetKey]
+	delete(layer, presetKey)
+
+	presetLayer := url.Values{}
+	var options []SrcsetOption
+	var err error
+	for _, name := range names {
+		p, ok := b.presets[name]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("%w %q", ErrUnknownPreset, name)
+			}
+			continue
+		}
+
+		presetParams := url.Values{}
+		for _, fn := range p.params {
+			fn(&presetParams)
+		}
+		delete(presetParams, presetKey)
+
+		if e := b.normalizeLayer(presetParams); e != nil && err == nil {
+			err = e
+		}
+
+		for k, v := range presetParams {
+			presetLayer[k] = v
+		}
+		options = append(options, p.options...)
+	}
+	return presetLayer, options, err
+}
@@ -0,0 +1,139 @@
+package imgix
+
+import (
+	"errors"
+	"testing"
+)
+
+func testPresetBuilder() URLBuilder {
+	return NewURLBuilder("test.imgix.net",
+		WithLibParam(false),
+		WithDefaultParams(Param("auto", "format")),
+		WithPreset("thumb",
+			[]IxParam{Param("w", "100"), Param("h", "100"), Param("fit", "crop"), Param("q", "75")},
+			WithVariableQuality(false)),
+		WithPreset("hero",
+			[]IxParam{Param("fit", "max"), Param("auto", "format", "compress")},
+			WithMinWidth(640),
+			WithMaxWidth(1280),
+			WithTolerance(0.2)))
+}
+
+func TestPreset_CreateURL(t *testing.T) {
+	u := testPresetBuilder()
+
+	got := u.CreateURL("image.png", Preset("thumb"), Param("q", "60"))
+	want := "https://test.imgix.net/image.png?auto=format&fit=crop&h=100&q=60&w=100"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// The preset's params replace the defaults.
+	got = u.CreateURL("image.png", Preset("hero"))
+	want = "https://test.imgix.net/image.png?auto=format%2Ccompress&fit=max"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestPreset_CreateSrcset(t *testing.T) {
+	u := testPresetBuilder()
+
+	got := u.CreateSrcset("image.png", []IxParam{Preset("hero")})
+	want := "https://test.imgix.net/image.png?auto=format%2Ccompress&fit=max&w=640 640w,\n" +
+		"https://test.imgix.net/image.png?auto=format%2Ccompress&fit=max&w=896 896w,\n" +
+		"https://test.imgix.net/image.png?auto=format%2Ccompress&fit=max&w=1254 1254w,\n" +
+		"https://test.imgix.net/image.png?auto=format%2Ccompress&fit=max&w=1280 1280w"
+	if got != want {
+		t.Errorf("\ngot: \n%s\n\nwant: \n%s", got, want)
+	}
+
+	// Options given to CreateSrcset are applied after the preset's options.
+	got = u.CreateSrcset("image.png", []IxParam{Preset("hero")}, WithMaxWidth(640))
+	want = "https://test.imgix.net/image.png?auto=format%2Ccompress&fit=max&w=640 640w"
+	if got != want {
+		t.Errorf("\ngot: \n%s\n\nwant: \n%s", got, want)
+	}
+
+	// The thumb preset disables variable quality, so its q is used throughout.
+	got = u.CreateSrcset("image.png", []IxParam{Preset("thumb"), Without("q")})
+	want = "https://test.imgix.net/image.png?auto=format&dpr=1&fit=crop&h=100&w=100 1x,\n" +
+		"https://test.imgix.net/image.png?auto=format&dpr=2&fit=crop&h=100&w=100 2x,\n" +
+		"https://test.imgix.net/image.png?auto=format&dpr=3&fit=crop&h=100&w=100 3x,\n" +
+		"https://test.imgix.net/image.png?auto=format&dpr=4&fit=crop&h=100&w=100 4x,\n" +
+		"https://test.imgix.net/image.png?auto=format&dpr=5&fit=crop&h=100&w=100 5x"
+	if got != want {
+		t.Errorf("\ngot: \n%s\n\nwant: \n%s", got, want)
+	}
+}
+
+func TestPreset_Unknown(t *testing.T) {
+	u := testPresetBuilder()
+
+	_, err := u.CreateURLE("image.png", Preset("avatar"))
+	if !errors.Is(err, ErrUnknownPreset) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrUnknownPreset)
+	}
+
+	_, err = u.CreateSrcsetE("image.png", []IxParam{Preset("avatar")})
+	if !errors.Is(err, ErrUnknownPreset) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrUnknownPreset)
+	}
+
+	_, err = u.CreateSrcsetFromWidthsE("image.png", []IxParam{Preset("avatar")}, []int{100})
+	if !errors.Is(err, ErrUnknownPreset) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrUnknownPreset)
+	}
+
+	// The non-E variants build nothing rather than leave the preset out.
+	if got := u.CreateURL("image.png", Preset("avatar"), Param("w", "100")); got != "" {
+		t.Errorf("\ngot:  %s\nwant: empty URL", got)
+	}
+
+	if got := u.CreateSrcset("image.png", []IxParam{Preset("avatar")}); got != "" {
+		t.Errorf("\ngot:  %s\nwant: empty srcset", got)
+	}
+
+	if got := u.CreateSrcsetFromWidths("image.png", []IxParam{Preset("avatar")}, []int{100}); got != "" {
+		t.Errorf("\ngot:  %s\nwant: empty srcset", got)
+	}
+
+	if got := u.CreateSrcsetEntries("image.png", []IxParam{Preset("avatar")}); got != nil {
+		t.Errorf("\ngot:  %v\nwant: nil entries", got)
+	}
+}
+
+func TestPreset_DefaultParams(t *testing.T) {
+	base := testPresetBuilder()
+	u := base.With(WithDefaultParams(Preset("thumb"), Param("fit", "max")))
+
+	// The preset applies beneath the other default params.
+	got := u.CreateURL("image.png")
+	want := "https://test.imgix.net/image.png?fit=max&h=100&q=75&w=100"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	got = u.CreateURL("image.png", Param("w", "200"))
+	want = "https://test.imgix.net/image.png?fit=max&h=100&q=75&w=200"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// The preset's srcset options apply too, so variable quality is off.
+	got = u.CreateSrcset("image.png", []IxParam{}, WithDevicePixelRatios(1, 2))
+	want = "https://test.imgix.net/image.png?dpr=1&fit=max&h=100&q=75&w=100 1x,\n" +
+		"https://test.imgix.net/image.png?dpr=2&fit=max&h=100&q=75&w=100 2x"
+	if got != want {
+		t.Errorf("\ngot: \n%s\n\nwant: \n%s", got, want)
+	}
+
+	unknown := base.With(WithDefaultParams(Preset("avatar")))
+	if _, err := unknown.CreateURLE("image.png"); !errors.Is(err, ErrUnknownPreset) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrUnknownPreset)
+	}
+
+	if got := unknown.CreateURL("image.png"); got != "" {
+		t.Errorf("\ngot:  %s\nwant: empty URL", got)
+	}
+}
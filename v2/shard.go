@@ -0,0 +1,178 @@
+package imgix
+
+import (
+	"hash/crc32"
+	"log"
+	"sort"
+	"sync/atomic"
+)
+
+// ShardStrategy determines how a sharded URLBuilder picks a domain for
+// each URL it creates. See NewShardedURLBuilder.
+type ShardStrategy int
+
+const (
+	// ShardCRC32 picks a domain by the CRC32 checksum of the URL's path.
+	// A given path is always served from the same domain, which keeps
+	// browser caches stable. This is the default strategy.
+	ShardCRC32 ShardStrategy = iota
+
+	// ShardRoundRobin picks each domain in turn.
+	ShardRoundRobin
+)
+
+// shard is one of the domains of a sharded builder. If the shard's
+// signer is nil, the builder's signer is used to sign its URLs.
+type shard struct {
+	domain string
+	signer Signer
+}
+
+// NewShardedURLBuilder creates a new URLBuilder that spreads the URLs it
+// creates over the given domains, e.g. several imgix domains or CNAMEs
+// for the same source. CreateURL, CreateSrcset, and CreateSrcsetFromWidths
+// each pick a domain per URL, according to the ShardStrategy given to
+// WithShardStrategy (ShardCRC32 by default). Every domain is signed with
+// the builder's token unless it is given its own by WithDomainToken.
+//
+// If a domain is invalid, NewShardedURLBuilder calls log.Fatal; use
+// NewShardedURLBuilderE to handle the error instead.
+func NewShardedURLBuilder(domains []string, options ...BuilderOption) URLBuilder {
+	urlBuilder, err := NewShardedURLBuilderE(domains, options...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return urlBuilder
+}
+
+// NewShardedURLBuilderE functions like NewShardedURLBuilder except that
+// it returns an error, rather than exiting, if a domain is invalid. The
+// builder's Domain is the first of the domains.
+func NewShardedURLBuilderE(domains []string, options ...BuilderOption) (URLBuilder, error) {
+	if len(domains) == 0 {
+		return URLBuilder{}, &DomainError{"", ErrDomainEmpty}
+	}
+
+	urlBuilder := URLBuilder{
+		useHTTPS:    true,
+		useLibParam: true,
+		domains:     append(make([]string, 0, len(domains)), domains...),
+		sharded:     true}
+
+	for _, fn := range options {
+		fn(&urlBuilder)
+	}
+
+	if err := urlBuilder.init(); err != nil {
+		return URLBuilder{}, err
+	}
+	return urlBuilder, nil
+}
+
+// initShards validates the domains of a sharded builder and pairs each
+// with a signer for the token given to it by WithDomainToken, if any.
+func (b *URLBuilder) initShards() error {
+	shards := make([]shard, 0, len(b.domains))
+	seen := make(map[string]bool, len(b.domains))
+	for _, domain := range b.domains {
+		validDomain, err := b.checkDomain(domain)
+		if err != nil {
+			return err
+		}
+		token := b.shardTokens[domain]
+		var signer Signer
+		if token != "" {
+			signer = b.newSigner(token)
+		}
+		shards = append(shards, shard{validDomain, signer})
+		seen[domain] = true
+	}
+
+	if err := b.checkShardTokens(seen); err != nil {
+		return err
+	}
+
+	b.shards = shards
+	if b.shardCounter == nil {
+		b.shardCounter = new(uint32)
+	}
+	return nil
+}
+
+// checkShardTokens checks that every domain given a token by
+// WithDomainToken is one of the sharded domains. Builders that are not
+// sharded have no sharded domains.
+func (b *URLBuilder) checkShardTokens(sharded map[string]bool) error {
+	domains := make([]string, 0, len(b.shardTokens))
+	for domain := range b.shardTokens {
+		if !sharded[domain] {
+			domains = append(domains, domain)
+		}
+	}
+
+	if len(domains) == 0 {
+		return nil
+	}
+	sort.Strings(domains)
+	return &DomainError{domains[0], ErrDomainNotSharded}
+}
+
+// WithShardStrategy returns a BuilderOption that NewShardedURLBuilder
+// consumes. The constructor uses this closure to set how the builder
+// picks a domain for each URL.
+func WithShardStrategy(strategy ShardStrategy) BuilderOption {
+	return func(b *URLBuilder) {
+		b.shardStrategy = strategy
+	}
+}
+
+// WithDomainToken returns a BuilderOption that NewShardedURLBuilder
+// consumes. URLs for the given domain are signed with the given token
+// rather than the builder's token. The domain must be given exactly as
+// it is passed to NewShardedURLBuilder; otherwise, or if the builder is
+// not sharded, the constructor returns a *DomainError that matches
+// ErrDomainNotSharded.
+func WithDomainToken(domain string, token string) BuilderOption {
+	return func(b *URLBuilder) {
+		shardTokens := make(map[string]string, len(b.shardTokens)+1)
+		for d, t := range b.shardTokens {
+			shardTokens[d] = t
+		}
+		shardTokens[domain] = token
+		b.shardTokens = shardTokens
+	}
+}
+
+// pickShard picks the domain, and its signer, for a URL with the given
+// (sanitized) path. Builders that are not sharded always use their domain.
+func (b *URLBuilder) pickShard(path []byte) shard {
+	n := uint32(len(b.shards))
+	if n == 0 {
+		return shard{domain: b.domain}
+	}
+
+	if b.shardStrategy == ShardRoundRobin {
+		return b.shards[(atomic.AddUint32(b.shardCounter, 1)-1)%n]
+	}
+	return b.shards[crc32.ChecksumIEEE(path)%n]
+}
+
+// shardOf returns the shard with the given domain. If there is no such
+// shard, a shard without a signer is returned.
+func (b *URLBuilder) shardOf(domain string) shard {
+	for _, s := range b.shards {
+		if s.domain == domain {
+			return s
+		}
+	}
+	return shard{domain: domain}
+}
+
+// signerFor returns the signer used to sign URLs for the given shard, or
+// nil if the URLs are not signed.
+func (b *URLBuilder) signerFor(s shard) Signer {
+	if s.signer != nil {
+		return s.signer
+	}
+	return b.signer
+}
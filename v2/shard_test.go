@@ -0,0 +1,125 @@
+package imgix
+
+import (
+	"errors"
+	"hash/crc32"
+	"strings"
+	"testing"
+)
+
+var testShards = []string{"a.imgix.net", "b.imgix.net", "c.imgix.net"}
+
+func TestShard_CRC32(t *testing.T) {
+	u := NewShardedURLBuilder(testShards, WithLibParam(false))
+
+	for _, path := range []string{"image.png", "users/1.png", "users/2.png", "~text"} {
+		want := testShards[crc32.ChecksumIEEE([]byte("/"+path))%3]
+
+		// The same path is always served from the same domain.
+		for i := 0; i < 3; i++ {
+			got := u.CreateURL(path)
+			if !strings.HasPrefix(got, "https://"+want+"/") {
+				t.Errorf("\ngot:  %s\nwant: %s", got, want)
+			}
+		}
+
+		srcset := u.CreateSrcsetFromWidths(path, []IxParam{}, []int{100, 200, 300})
+		for _, entry := range strings.Split(srcset, ",\n") {
+			if !strings.HasPrefix(entry, "https://"+want+"/") {
+				t.Errorf("\ngot:  %s\nwant: %s", entry, want)
+			}
+		}
+	}
+}
+
+func TestShard_RoundRobin(t *testing.T) {
+	u := NewShardedURLBuilder(testShards,
+		WithLibParam(false),
+		WithShardStrategy(ShardRoundRobin))
+
+	want := "https://a.imgix.net/image.png?dpr=1&q=75&w=320 1x,\n" +
+		"https://b.imgix.net/image.png?dpr=2&q=50&w=320 2x,\n" +
+		"https://c.imgix.net/image.png?dpr=3&q=35&w=320 3x,\n" +
+		"https://a.imgix.net/image.png?dpr=4&q=23&w=320 4x,\n" +
+		"https://b.imgix.net/image.png?dpr=5&q=20&w=320 5x"
+	got := u.CreateSrcset("image.png", []IxParam{Param("w", "320")})
+	if got != want {
+		t.Errorf("\ngot: \n%s\n\nwant: \n%s", got, want)
+	}
+
+	gotURL := u.CreateURL("image.png")
+	wantURL := "https://c.imgix.net/image.png"
+	if gotURL != wantURL {
+		t.Errorf("\ngot:  %s\nwant: %s", gotURL, wantURL)
+	}
+}
+
+func TestShard_DomainTokens(t *testing.T) {
+	u := NewShardedURLBuilder(testShards,
+		WithToken("FOO123bar"),
+		WithDomainToken("c.imgix.net", "BAZ456qux"),
+		WithShardStrategy(ShardRoundRobin))
+
+	for i := 0; i < 3; i++ {
+		rawURL := u.CreateURL("users/1.png", Param("w", "400"))
+		if err := u.Verify(rawURL); err != nil {
+			t.Errorf("%s\ngot: err == %v; want: err == nil", rawURL, err)
+		}
+
+		wantToken := "FOO123bar"
+		if strings.HasPrefix(rawURL, "https://c.imgix.net/") {
+			wantToken = "BAZ456qux"
+		}
+
+		if err := NewVerifier(wantToken).Verify(rawURL); err != nil {
+			t.Errorf("%s\ngot: err == %v; want: err == nil", rawURL, err)
+		}
+	}
+}
+
+func TestShard_DomainsCopied(t *testing.T) {
+	domains := []string{"a.imgix.net", "b.imgix.net"}
+	u := NewShardedURLBuilder(domains, WithShardStrategy(ShardRoundRobin), WithLibParam(false))
+
+	// Changing the caller's slice does not change the builder's domains,
+	// even for builders derived from it with With.
+	domains[0] = "other.imgix.net"
+	v := u.With(WithHTTPS(true))
+
+	got := v.CreateURL("image.png")
+	want := "https://a.imgix.net/image.png"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestShard_Invalid(t *testing.T) {
+	_, err := NewShardedURLBuilderE([]string{})
+	if !errors.Is(err, ErrDomainEmpty) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrDomainEmpty)
+	}
+
+	_, err = NewShardedURLBuilderE(
+		[]string{"a.imgix.net", "b.imgix.net/foo"},
+		WithStrictDomain(true))
+	if !errors.Is(err, ErrDomainHasPath) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrDomainHasPath)
+	}
+
+	_, err = NewShardedURLBuilderE(testShards, WithDomainToken("d.imgix.net", "FOO123bar"))
+	if !errors.Is(err, ErrInvalidDomain) || !errors.Is(err, ErrDomainNotSharded) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrDomainNotSharded)
+	}
+
+	// Builders that are not sharded reject domain tokens too.
+	_, err = NewURLBuilderE("a.imgix.net", WithDomainToken("a.imgix.net", "FOO123bar"))
+	if !errors.Is(err, ErrDomainNotSharded) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrDomainNotSharded)
+	}
+
+	u := NewURLBuilder("a.imgix.net")
+	_, err = u.WithE(WithDomainToken("a.imgix.net", "FOO123bar"))
+	if !errors.Is(err, ErrDomainNotSharded) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrDomainNotSharded)
+	}
+}
@@ -0,0 +1,97 @@
+package imgix
+
+import (
+	"crypto/md5"
+	"encoding"
+	"encoding/hex"
+	"hash"
+	"io"
+	"sync"
+)
+
+// Signer creates the signatures of URLs. A URLBuilder passes each URL's
+// escaped path (e.g. "/users/1.png") and encoded query, without the
+// leading '?' (e.g. "w=400&h=300"), exactly as they appear in the URL. The
+// query is empty if the URL has no params. The returned signature is
+// appended to the URL as the "s" param, so it must be safe for use in a
+// query string.
+//
+// By default, URLs are signed with the MD5 scheme used by imgix (see
+// NewMD5Signer). A Signer must be safe for concurrent use.
+type Signer interface {
+	Sign(path string, query string) string
+}
+
+// appendSigner is implemented by signers that can append a signature to a
+// buffer without allocating, e.g. the MD5 signer.
+type appendSigner interface {
+	appendSignature(dst []byte, path []byte, query []byte) []byte
+}
+
+// appendSignature appends the signature of the path and query to dst.
+func appendSignature(dst []byte, s Signer, path []byte, query []byte) []byte {
+	if as, ok := s.(appendSigner); ok {
+		return as.appendSignature(dst, path, query)
+	}
+	return append(dst, s.Sign(string(path), string(query))...)
+}
+
+// NewMD5Signer returns a Signer that creates the signatures imgix expects
+// of a source with the given secure token: the hex-encoded MD5 hash of the
+// token, the path, and, if there is a query, '?' and the query. This is
+// the Signer a URLBuilder uses when given a token with WithToken.
+func NewMD5Signer(token string) Signer {
+	return newMD5Signer(token)
+}
+
+// md5Pool pools the hashes used by md5Signer.
+var md5Pool = sync.Pool{New: func() interface{} { return md5.New() }}
+
+// md5Signer creates the MD5 signatures of URLs for a given token. The
+// state of the hash after writing the token is computed once, when the
+// signer is created, and restored for every signature.
+type md5Signer struct {
+	token string // A source's secure token.
+	state []byte // The marshaled state of an MD5 hash after writing the token.
+}
+
+// newMD5Signer creates a new md5Signer for the given token.
+func newMD5Signer(token string) *md5Signer {
+	h := md5.New()
+	io.WriteString(h, token)
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	return &md5Signer{token: token, state: state}
+}
+
+// Sign returns the hex-encoded MD5 signature of the path and query.
+func (s *md5Signer) Sign(path string, query string) string {
+	return string(s.appendSignature(nil, []byte(path), []byte(query)))
+}
+
+// appendSignature appends the hex-encoded signature of the (encoded)
+// path and query to dst. The signature base has the form:
+// {TOKEN}{PATH}{DELIM}{QUERY}, where the delimiter is '?' if there is a
+// query and empty otherwise.
+func (s *md5Signer) appendSignature(dst []byte, path []byte, query []byte) []byte {
+	h := md5Pool.Get().(hash.Hash)
+	defer md5Pool.Put(h)
+
+	h.(encoding.BinaryUnmarshaler).UnmarshalBinary(s.state)
+	h.Write(path)
+	if len(query) > 0 {
+		h.Write(querySeparator)
+		h.Write(query)
+	}
+
+	// Sum the hash into dst, then hex-encode the sum in place.
+	var sum [md5.Size]byte
+	start := len(dst)
+	dst = h.Sum(dst)
+	copy(sum[:], dst[start:])
+	dst = append(dst[:start], make([]byte, hex.EncodedLen(md5.Size))...)
+	hex.Encode(dst[start:], sum[:])
+	return dst
+}
+
+// querySeparator separates the path and query in a signature base.
+var querySeparator = []byte("?")
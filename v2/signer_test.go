@@ -0,0 +1,117 @@
+package imgix
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeSigner signs URLs with a readable, deterministic signature.
+type fakeSigner struct{}
+
+func (fakeSigner) Sign(path string, query string) string {
+	return "signed:" + path
+}
+
+// hmacSigner signs URLs with HMAC-SHA256, as a self-hosted origin might.
+type hmacSigner struct {
+	key []byte
+}
+
+func (s hmacSigner) Sign(path string, query string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(path))
+	if query != "" {
+		mac.Write([]byte("?" + query))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSigner_MD5Signer(t *testing.T) {
+	s := NewMD5Signer("FOO123bar")
+
+	got := s.Sign("/users/1.png", "h=300&w=400")
+	want := "1a4e48641614d1109c6a7af51be23d18"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestSigner_WithSigner(t *testing.T) {
+	u := NewURLBuilder("test.imgix.net", WithSigner(fakeSigner{}), WithLibParam(false))
+
+	got := u.CreateURL("users/1.png", Param("w", "400"))
+	want := "https://test.imgix.net/users/1.png?w=400&s=signed:/users/1.png"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestSigner_WithSignerOverridesToken(t *testing.T) {
+	signer := hmacSigner{[]byte("secret")}
+	u := NewURLBuilder("test.imgix.net",
+		WithToken("FOO123bar"), WithSigner(signer), WithLibParam(false))
+
+	got := u.CreateURL("users/1.png", Param("w", "400"))
+	want := "https://test.imgix.net/users/1.png?w=400&s=" + signer.Sign("/users/1.png", "w=400")
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestSigner_WithSignerSharded(t *testing.T) {
+	u := NewShardedURLBuilder(testShards,
+		WithSigner(fakeSigner{}),
+		WithDomainToken("c.imgix.net", "BAZ456qux"),
+		WithShardStrategy(ShardRoundRobin),
+		WithLibParam(false))
+
+	for _, path := range []string{"1.png", "2.png", "3.png", "4.png"} {
+		got := u.CreateURL(path)
+		if want := "?s=signed:/" + path; !strings.HasSuffix(got, want) {
+			t.Errorf("\ngot:  %s\nwant: suffix %s", got, want)
+		}
+	}
+}
+
+func TestSigner_VerifyAndResign(t *testing.T) {
+	oldSigner := hmacSigner{[]byte("old")}
+	newSigner := hmacSigner{[]byte("new")}
+
+	old := NewURLBuilder("test.imgix.net", WithSigner(oldSigner))
+	issued := old.CreateURL("users/1.png", Param("w", "400"))
+
+	v := NewSignerVerifier(newSigner, oldSigner)
+	if err := v.Verify(issued); err != nil {
+		t.Errorf("got: err == %v; want: err == nil", err)
+	}
+
+	u := NewURLBuilder("test.imgix.net", WithSigner(newSigner))
+	if err := u.Verify(issued); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("got: err == %v; want: err == %v", err, ErrSignatureMismatch)
+	}
+
+	resigned, err := old.Resign(issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resigned != issued {
+		t.Errorf("\ngot:  %s\nwant: %s", resigned, issued)
+	}
+
+	tampered := strings.Replace(issued, "w=400", "w=800", 1)
+	if err := v.Verify(tampered); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("got: err == %v; want: err == %v", err, ErrSignatureMismatch)
+	}
+}
+
+func TestSigner_VerifyMissingSigner(t *testing.T) {
+	v := NewSignerVerifier(nil)
+	err := v.Verify("https://test.imgix.net/users/1.png?s=abc")
+	if !errors.Is(err, ErrMissingToken) {
+		t.Errorf("got: err == %v; want: err == %v", err, ErrMissingToken)
+	}
+}
@@ -1,11 +1,12 @@
 package imgix
 
 import (
+	"fmt"
+	"io"
 	"log"
 	"math"
 	"net/url"
 	"strconv"
-	"strings"
 )
 
 // defaultMinWidth is the default minimum width used within a
@@ -42,6 +43,11 @@ type SrcsetOpts struct {
 	maxWidth        int
 	tolerance       float64
 	variableQuality bool
+	dprs            []float64             // The device pixel ratios of fixed-width srcsets.
+	quality         func(dpr float64) int // The quality of each ratio; nil for the defaults.
+	aspectRatio     string                // The W:H aspect ratio of each candidate, if any.
+	intrinsicWidth  int                   // The width of the source image; zero if unknown.
+	intrinsicHeight int                   // The height of the source image; zero if unknown.
 }
 
 type SrcsetOption func(opt *SrcsetOpts)
@@ -50,7 +56,7 @@ type SrcsetOption func(opt *SrcsetOpts)
 // IxParam parameters, and a set of SrcsetOptions, this function infers
 // which kind of srcset attribute to create.
 //
-// If the params contain a width or height parameter, a fixed-width srcset 
+// If the params contain a width or height parameter, a fixed-width srcset
 // attribute will be created. This fixed-width srcset attribute will be
 // dpr-based and have variable quality enabled by default. Variable
 // quality can be disabled by passing WithVariableQuality(false).
@@ -58,24 +64,114 @@ type SrcsetOption func(opt *SrcsetOpts)
 // Otherwise if no explicit width or height were found this function will
 // create a fluid-width srcset attribute wherein each URL (or image candidate
 // string) is described by a width in specified width range.
+//
+// If the params cannot be applied, e.g. if a param references an unknown
+// preset or fails strict param validation, CreateSrcset returns an empty
+// string, as CreateURL does. If the width range or tolerance is invalid,
+// CreateSrcset calls log.Fatal. Use CreateSrcsetE to handle either error
+// instead.
 func (b *URLBuilder) CreateSrcset(
 	path string,
 	params []IxParam,
 	options ...SrcsetOption) string {
 
-	urlParams := url.Values{}
+	urlParams, info, err := b.buildValues(params)
+	if err != nil {
+		return ""
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	srcset, err := b.appendSrcsetValues(*buf, path, urlParams, info, options, nil)
+	*buf = srcset
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return string(srcset)
+}
+
+// CreateSrcsetE functions like CreateSrcset except that it returns an
+// error, rather than exiting, if the width range or tolerance is invalid,
+// and if the params cannot be applied (see CreateURLE), e.g. if a param
+// references an unknown preset. The error matches one of
+// ErrInvalidWidthRange, ErrInvalidTolerance, or ErrUnknownPreset when
+// compared with errors.Is.
+//
+// The srcset options of any presets referenced by the params are applied
+// before the given options.
+func (b *URLBuilder) CreateSrcsetE(
+	path string,
+	params []IxParam,
+	options ...SrcsetOption) (string, error) {
+
+	buf := getBuffer()
+	defer putBuffer(buf)
 
-	for _, fn := range params {
-		fn(&urlParams)
+	srcset, err := b.appendSrcset(*buf, path, params, options, nil)
+	*buf = srcset
+	if err != nil {
+		return "", err
 	}
+	return string(srcset), nil
+}
 
+// WriteSrcset functions like CreateSrcsetE except that it writes the
+// srcset attribute to w, rather than returning it as a string.
+func (b *URLBuilder) WriteSrcset(
+	w io.Writer,
+	path string,
+	params []IxParam,
+	options ...SrcsetOption) error {
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	srcset, err := b.appendSrcset(*buf, path, params, options, nil)
+	*buf = srcset
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(srcset)
+	return err
+}
+
+// appendSrcset appends the srcset attribute described by the params and
+// options to dst. If entries is not nil, an entry is appended to it for
+// each image candidate. See CreateSrcset.
+func (b *URLBuilder) appendSrcset(
+	dst []byte,
+	path string,
+	params []IxParam,
+	options []SrcsetOption,
+	entries *SrcsetEntries) ([]byte, error) {
+
+	urlParams, info, err := b.buildValues(params)
+	if err != nil {
+		return dst, err
+	}
+	return b.appendSrcsetValues(dst, path, urlParams, info, options, entries)
+}
+
+// appendSrcsetValues functions like appendSrcset except that it accepts
+// the params built by buildValues.
+func (b *URLBuilder) appendSrcsetValues(
+	dst []byte,
+	path string,
+	urlParams url.Values,
+	info layerInfo,
+	options []SrcsetOption,
+	entries *SrcsetEntries) ([]byte, error) {
+
+	var err error
 	opts := SrcsetOpts{
 		minWidth:        defaultMinWidth,
 		maxWidth:        defaultMaxWidth,
 		tolerance:       defaultTolerance,
 		variableQuality: true}
 
-	for _, fn := range options {
+	for _, fn := range append(info.options, options...) {
 		fn(&opts)
 	}
 
@@ -83,16 +179,89 @@ func (b *URLBuilder) CreateSrcset(
 	hasWidth := urlParams.Get("w") != ""
 	hasHeight := urlParams.Get("h") != ""
 
+	var ratio float64
+	if opts.aspectRatio != "" {
+		rw, rh, ok := parseRatio(opts.aspectRatio)
+		if !ok {
+			return dst, fmt.Errorf("%w: want W:H, e.g. 16:9, found %q",
+				ErrInvalidAspectRatio, opts.aspectRatio)
+		}
+		ratio = rh / rw
+	}
+
+	if opts.intrinsicWidth < 0 || opts.intrinsicHeight < 0 {
+		return dst, fmt.Errorf("%w: width and height must be greater than, or equal to, zero, found %dx%d",
+			ErrInvalidIntrinsicSize, opts.intrinsicWidth, opts.intrinsicHeight)
+	}
+	intrinsic := srcsetSize{opts.intrinsicWidth, opts.intrinsicHeight}
+
 	// If params has either a width or height,
 	// build a dpr-based srcset attribute.
 	if hasWidth || hasHeight {
-		return b.buildSrcSetDpr(path, urlParams, opts.variableQuality)
+		dprs := defaultDPRs
+		if opts.dprs != nil {
+			dprs, err = validateDPRs(opts.dprs)
+			if err != nil {
+				return dst, err
+			}
+		}
+
+		// A default q gives way to the quality of each ratio.
+		if opts.variableQuality && info.defaultQuality {
+			delete(urlParams, "q")
+		}
+
+		var quality func(float64) int
+		if opts.variableQuality {
+			quality = opts.quality
+			if quality == nil {
+				quality = defaultDPRQuality
+			}
+		}
+
+		// Given an aspect ratio, a fixed width or height is scaled by each
+		// ratio and described by the resulting width.
+		if ratio != 0 && hasWidth != hasHeight {
+			if sizes, ok := fixedRatioSizes(urlParams, ratio, dprs); ok {
+				sizes, dprs = intrinsic.fitSizes(sizes, dprs)
+				return b.appendSrcSetSizes(dst, path, urlParams, sizes, dprs, quality, entries)
+			}
+		}
+
+		if intrinsic != (srcsetSize{}) {
+			_, dprs = intrinsic.fitSizes(fixedSizes(urlParams, dprs), dprs)
+		}
+		return b.appendSrcSetDpr(dst, path, urlParams, dprs, quality, entries)
 	}
 
 	// Otherwise, get the widthRange values from the opts and build a
 	// width-pairs based srcset attribute.
-	targets := TargetWidths(opts.minWidth, opts.maxWidth, opts.tolerance)
-	return b.buildSrcSetPairs(path, urlParams, targets)
+	targets, err := TargetWidthsE(opts.minWidth, opts.maxWidth, opts.tolerance)
+	if err != nil {
+		return dst, err
+	}
+
+	// Widths past the intrinsic size (or, given an aspect ratio, widths
+	// whose heights are past it) are replaced by a single candidate at the
+	// largest width that fits.
+	maxWidth := intrinsic.width
+	if ratio != 0 && intrinsic.height > 0 {
+		if w := int(float64(intrinsic.height) / ratio); maxWidth == 0 || w < maxWidth {
+			maxWidth = w
+		}
+	}
+	if maxWidth > 0 {
+		targets = capWidths(targets, maxWidth)
+	}
+
+	if ratio != 0 {
+		sizes := make([]srcsetSize, len(targets))
+		for idx, w := range targets {
+			sizes[idx] = srcsetSize{w, roundSide(float64(w) * ratio)}
+		}
+		return b.appendSrcSetSizes(dst, path, urlParams, sizes, nil, nil, entries)
+	}
+	return b.appendSrcSetPairs(dst, path, urlParams, targets, entries), nil
 }
 
 func WithMinWidth(minWidth int) SrcsetOption {
@@ -119,86 +288,452 @@ func WithVariableQuality(variableQuality bool) SrcsetOption {
 	}
 }
 
+// WithDevicePixelRatios sets the device pixel ratios of fixed-width
+// srcsets, replacing the default of 1x through 5x. Fractional ratios, e.g.
+// 1.5, are allowed. The candidates are sorted by ratio and duplicate
+// ratios are dropped. Each ratio must be greater than zero and at most
+// 10; otherwise CreateSrcsetE returns an error matching ErrInvalidDPR.
+func WithDevicePixelRatios(dprs ...float64) SrcsetOption {
+	dprs = append(make([]float64, 0, len(dprs)), dprs...)
+	return func(s *SrcsetOpts) {
+		s.dprs = dprs
+	}
+}
+
+// WithDPRQualities sets the quality (q) of each device pixel ratio of a
+// fixed-width srcset when variable quality is enabled. Ratios missing
+// from the map use the default quality; see WithDPRQualityFunc.
+func WithDPRQualities(qualities map[float64]int) SrcsetOption {
+	copied := make(map[float64]int, len(qualities))
+	for dpr, q := range qualities {
+		copied[dpr] = q
+	}
+
+	return WithDPRQualityFunc(func(dpr float64) int {
+		if q, ok := copied[dpr]; ok {
+			return q
+		}
+		return defaultDPRQuality(dpr)
+	})
+}
+
+// WithDPRQualityFunc sets a function that returns the quality (q) of each
+// device pixel ratio of a fixed-width srcset when variable quality is
+// enabled. By default, 1x through 5x have the qualities 75, 50, 35, 23,
+// and 20, and the qualities of fractional ratios are interpolated between
+// them, e.g. 63 for 1.5x. A quality outside of 0 to 100 makes
+// CreateSrcsetE return an error matching ErrInvalidQuality.
+func WithDPRQualityFunc(quality func(dpr float64) int) SrcsetOption {
+	return func(s *SrcsetOpts) {
+		s.quality = quality
+	}
+}
+
+// WithAspectRatio sets the aspect ratio, of the form W:H (e.g. "16:9"), of
+// every image candidate. Rather than leaving imgix to infer each height,
+// fluid-width srcsets set an explicit h, rounded to the nearest pixel, for
+// each target width. Given a fixed integer w or h (but not both), the
+// other side is derived from the ratio, both are scaled by each device
+// pixel ratio, and the candidates are described by their widths rather
+// than by "x" descriptors. Pair this with fit=crop so that imgix crops to
+// the ratio.
+//
+// If the ratio is malformed, CreateSrcsetE returns an error matching
+// ErrInvalidAspectRatio.
+func WithAspectRatio(ratio string) SrcsetOption {
+	return func(s *SrcsetOpts) {
+		s.aspectRatio = ratio
+	}
+}
+
+// WithIntrinsicSize sets the width and height of the source image, so that
+// srcsets do not offer candidates that would only be upscaled copies of
+// it. Fluid-width srcsets are capped at the intrinsic width, ending with a
+// candidate at exactly that width. Fixed-width srcsets drop the device
+// pixel ratios at which the image would be wider or taller than the
+// source, though the smallest ratio is always kept. Either side may be
+// zero if it is unknown.
+//
+// If either side is negative, CreateSrcsetE returns an error matching
+// ErrInvalidIntrinsicSize.
+func WithIntrinsicSize(width int, height int) SrcsetOption {
+	return func(s *SrcsetOpts) {
+		s.intrinsicWidth = width
+		s.intrinsicHeight = height
+	}
+}
+
 // CreateSrcsetFromWidths takes a path, a set of params, and an array of widths
 // to create a srcset attribute with width-described URLs (image candidate strings).
+//
+// If a width is negative or the params cannot be applied, e.g. if a param
+// references an unknown preset or fails strict param validation,
+// CreateSrcsetFromWidths returns an empty string, as CreateURL does; use
+// CreateSrcsetFromWidthsE to find out why.
 func (b *URLBuilder) CreateSrcsetFromWidths(path string, params []IxParam, widths []int) string {
-	urlParams := url.Values{}
+	srcset, _ := b.CreateSrcsetFromWidthsE(path, params, widths)
+	return srcset
+}
+
+// CreateSrcsetFromWidthsE functions like CreateSrcsetFromWidths except that
+// it returns an error if a width is negative or the params cannot be
+// applied (see CreateURLE), e.g. if a param references an unknown preset.
+// The error matches ErrInvalidWidth or ErrUnknownPreset when compared with
+// errors.Is. The srcset options of presets do not apply, since the widths
+// are given explicitly.
+func (b *URLBuilder) CreateSrcsetFromWidthsE(path string, params []IxParam, widths []int) (string, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	srcset, err := b.appendSrcsetFromWidths(*buf, path, params, widths, nil)
+	*buf = srcset
+	if err != nil {
+		return "", err
+	}
+	return string(srcset), nil
+}
+
+// WriteSrcsetFromWidths functions like CreateSrcsetFromWidthsE except that
+// it writes the srcset attribute to w, rather than returning it as a string.
+func (b *URLBuilder) WriteSrcsetFromWidths(w io.Writer, path string, params []IxParam, widths []int) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
 
-	for _, fn := range params {
-		fn(&urlParams)
+	srcset, err := b.appendSrcsetFromWidths(*buf, path, params, widths, nil)
+	*buf = srcset
+	if err != nil {
+		return err
 	}
 
-	return b.buildSrcSetPairs(path, urlParams, widths)
+	_, err = w.Write(srcset)
+	return err
+}
+
+// appendSrcsetFromWidths appends a srcset attribute with the given widths
+// to dst, and an entry for each image candidate to entries if it is not
+// nil. See CreateSrcsetFromWidths.
+func (b *URLBuilder) appendSrcsetFromWidths(
+	dst []byte,
+	path string,
+	params []IxParam,
+	widths []int,
+	entries *SrcsetEntries) ([]byte, error) {
+
+	if _, err := validateWidths(widths); err != nil {
+		return dst, err
+	}
+
+	urlParams, _, err := b.buildValues(params)
+	if err != nil {
+		return dst, err
+	}
+	return b.appendSrcSetPairs(dst, path, urlParams, widths, entries), nil
 }
 
-// buildSrcSetPairs builds a srcset attribute string containing width-described
-// image candidate strings.
-func (b *URLBuilder) buildSrcSetPairs(path string, params url.Values, targets []int) string {
-	var srcSetEntries []string
+// appendSrcSetPairs appends a srcset attribute containing width-described
+// image candidate strings to dst. The path is sanitized only once, and each
+// candidate's URL is built directly in dst.
+func (b *URLBuilder) appendSrcSetPairs(
+	dst []byte,
+	path string,
+	params url.Values,
+	targets []int,
+	entries *SrcsetEntries) []byte {
+
+	pathBuf := getBuffer()
+	defer putBuffer(pathBuf)
+	*pathBuf = b.appendPath(*pathBuf, path)
+
+	widthValue := []string{""}
+	params["w"] = widthValue
+
+	for idx, w := range targets {
+		if idx > 0 {
+			dst = append(dst, srcsetSeparator...)
+		}
 
-	for _, w := range targets {
-		widthValue := strconv.Itoa(w)
-		params.Set("w", widthValue)
-		entry := b.createImageCandidateString(path, params, widthValue+"w")
-		srcSetEntries = append(srcSetEntries, entry)
+		widthValue[0] = strconv.Itoa(w)
+		dst = b.appendCandidate(dst, *pathBuf, params, WidthDescriptor, float64(w), entries)
 	}
-	return strings.Join(srcSetEntries, ",\n")
+	return dst
 }
 
-func (b *URLBuilder) buildSrcSetDpr(path string, params url.Values, useVariableQuality bool) string {
-	var DprQualities = map[string]string{"1": "75", "2": "50", "3": "35", "4": "23", "5": "20"}
-	var srcSetEntries []string
+// srcsetSize is the width and height of an image candidate.
+type srcsetSize struct {
+	width  int
+	height int
+}
+
+// fitSizes returns the sizes that fit within the intrinsic size s, along
+// with the device pixel ratios at the same indexes. A side of zero, in
+// either s or a size, is unknown and always fits. If no size fits, the
+// first is kept.
+func (s srcsetSize) fitSizes(sizes []srcsetSize, dprs []float64) ([]srcsetSize, []float64) {
+	if s.width == 0 && s.height == 0 {
+		return sizes, dprs
+	}
+
+	fitted := make([]srcsetSize, 0, len(sizes))
+	fittedDPRs := make([]float64, 0, len(dprs))
+	for idx, size := range sizes {
+		fitsWidth := s.width == 0 || size.width <= s.width
+		fitsHeight := s.height == 0 || size.height <= s.height
+		if fitsWidth && fitsHeight || idx == 0 {
+			fitted = append(fitted, size)
+			fittedDPRs = append(fittedDPRs, dprs[idx])
+		}
+	}
+	return fitted, fittedDPRs
+}
+
+// capWidths returns the widths less than maxWidth, followed by maxWidth
+// if any width reaches it. The widths must be increasing. A new slice is
+// returned, since the widths may be DefaultWidths.
+func capWidths(widths []int, maxWidth int) []int {
+	capped := make([]int, 0, len(widths))
+	for _, w := range widths {
+		if w >= maxWidth {
+			return append(capped, maxWidth)
+		}
+		capped = append(capped, w)
+	}
+	return capped
+}
+
+// roundSide rounds the length of an image's side to the nearest pixel,
+// and to at least one pixel.
+func roundSide(side float64) int {
+	if side < 1 {
+		return 1
+	}
+	return int(math.Round(side))
+}
+
+// fixedRatioSizes returns the size of a fixed-width or fixed-height image
+// with the given ratio (of height to width) at each device pixel ratio.
+// It reports false if the fixed side is not a positive integer.
+func fixedRatioSizes(params url.Values, ratio float64, dprs []float64) ([]srcsetSize, bool) {
+	var width, height float64
+	if w := params.Get("w"); w != "" {
+		n, err := strconv.Atoi(w)
+		if err != nil || n <= 0 {
+			return nil, false
+		}
+		width, height = float64(n), float64(n)*ratio
+	} else {
+		n, err := strconv.Atoi(params.Get("h"))
+		if err != nil || n <= 0 {
+			return nil, false
+		}
+		width, height = float64(n)/ratio, float64(n)
+	}
+
+	sizes := make([]srcsetSize, len(dprs))
+	for idx, dpr := range dprs {
+		sizes[idx] = srcsetSize{roundSide(width * dpr), roundSide(height * dpr)}
+	}
+	return sizes, true
+}
+
+// fixedSizes returns the size of a fixed-width or fixed-height image at
+// each device pixel ratio. A side that is not given as a positive integer
+// is unknown and left as zero.
+func fixedSizes(params url.Values, dprs []float64) []srcsetSize {
+	width, _ := strconv.Atoi(params.Get("w"))
+	height, _ := strconv.Atoi(params.Get("h"))
+
+	sizes := make([]srcsetSize, len(dprs))
+	for idx, dpr := range dprs {
+		if width > 0 {
+			sizes[idx].width = roundSide(float64(width) * dpr)
+		}
+		if height > 0 {
+			sizes[idx].height = roundSide(float64(height) * dpr)
+		}
+	}
+	return sizes
+}
+
+// appendSrcSetSizes appends a srcset attribute containing width-described
+// image candidate strings, each with an explicit w and h, to dst. If
+// quality is not nil and the params have no q, each candidate's q is set
+// to the quality of the device pixel ratio at the same index in dprs.
+func (b *URLBuilder) appendSrcSetSizes(
+	dst []byte,
+	path string,
+	params url.Values,
+	sizes []srcsetSize,
+	dprs []float64,
+	quality func(float64) int,
+	entries *SrcsetEntries) ([]byte, error) {
+
+	pathBuf := getBuffer()
+	defer putBuffer(pathBuf)
+	*pathBuf = b.appendPath(*pathBuf, path)
+
+	widthValue := []string{""}
+	heightValue := []string{""}
+	params["w"] = widthValue
+	params["h"] = heightValue
 
 	qValue := params.Get("q")
-	// We could iterate over the map directly, but that doesn't yield
-	// deterministic results, ie. 5x might come before 1x in the final
-	// srcset attribute string. To prevent this, we iterate over the
-	// map "in order."
-	for i := 0; i < len(DprQualities); i++ {
-		ratio := strconv.Itoa(i + 1)
-		params.Set("dpr", ratio)
-		dprQuality := DprQualities[ratio]
+	qValues := []string{qValue}
+	if quality != nil && qValue == "" {
+		params["q"] = qValues
+	}
 
-		if useVariableQuality && qValue != "" {
-			params.Set("q", qValue)
-		} else if useVariableQuality {
-			params.Set("q", dprQuality)
-		} else if qValue != "" {
-			params.Set("q", qValue)
+	for idx, size := range sizes {
+		if idx > 0 {
+			dst = append(dst, srcsetSeparator...)
 		}
 
-		entry := b.createImageCandidateString(path, params, ratio+"x")
-		srcSetEntries = append(srcSetEntries, entry)
+		widthValue[0] = strconv.Itoa(size.width)
+		heightValue[0] = strconv.Itoa(size.height)
+		if quality != nil && qValue == "" {
+			q, err := qualityOf(quality, dprs[idx])
+			if err != nil {
+				return dst, err
+			}
+			qValues[0] = q
+		}
+
+		dst = b.appendCandidate(dst, *pathBuf, params, WidthDescriptor, float64(size.width), entries)
 	}
-	return strings.Join(srcSetEntries, ",\n")
+	return dst, nil
 }
 
-// createImageCandidateString joins a URL with a space and a suffix in order
-// to create an image candidate string. For more information see:
-// https://html.spec.whatwg.org/multipage/images.html#srcset-attributes
-func (b *URLBuilder) createImageCandidateString(path string, params url.Values, suffix string) string {
-	return strings.Join([]string{b.createURLFromValues(path, params), " ", suffix}, "")
+// dprQualities are the default qualities of each device pixel ratio, from
+// 1x to 5x, used by fixed-width srcsets when variable quality is enabled.
+var dprQualities = [...]int{75, 50, 35, 23, 20}
+
+// defaultDPRs are the default device pixel ratios of fixed-width srcsets.
+var defaultDPRs = []float64{1, 2, 3, 4, 5}
+
+// defaultDPRQuality returns the default quality of a device pixel ratio.
+// The qualities of ratios between 1x and 5x are interpolated linearly
+// between those of the neighboring whole ratios; ratios below 1x and
+// above 5x have the qualities of 1x and 5x.
+func defaultDPRQuality(dpr float64) int {
+	last := len(dprQualities) - 1
+	if dpr <= 1 {
+		return dprQualities[0]
+	}
+	if dpr >= float64(last+1) {
+		return dprQualities[last]
+	}
+
+	i := int(dpr) - 1
+	lo, hi := float64(dprQualities[i]), float64(dprQualities[i+1])
+	return int(math.Round(lo + (hi-lo)*(dpr-float64(i+1))))
+}
+
+// qualityOf returns the quality of a device pixel ratio, as given by the
+// quality function, formatted as a q value. The quality must be between 0
+// and 100.
+func qualityOf(quality func(float64) int, dpr float64) (string, error) {
+	q := quality(dpr)
+	if q < 0 || q > 100 {
+		return "", fmt.Errorf("%w: quality of %sx must be between 0 and 100, found %d",
+			ErrInvalidQuality, formatDPR(dpr), q)
+	}
+	return strconv.Itoa(q), nil
+}
+
+// formatDPR formats a device pixel ratio for use as a dpr value and in an
+// "x" descriptor, e.g. "2" or "1.5". Whole ratios are formatted without
+// allocating.
+func formatDPR(dpr float64) string {
+	if dpr == math.Trunc(dpr) && dpr < 100 {
+		return strconv.Itoa(int(dpr))
+	}
+	return formatFloat(dpr)
+}
+
+// appendSrcSetDpr appends a srcset attribute containing dpr-described
+// image candidate strings to dst, one for each of the ratios. If quality
+// is not nil and the params have no q, each candidate's q is set to the
+// quality of its ratio.
+func (b *URLBuilder) appendSrcSetDpr(
+	dst []byte,
+	path string,
+	params url.Values,
+	dprs []float64,
+	quality func(float64) int,
+	entries *SrcsetEntries) ([]byte, error) {
+
+	pathBuf := getBuffer()
+	defer putBuffer(pathBuf)
+	*pathBuf = b.appendPath(*pathBuf, path)
+
+	qValue := params.Get("q")
+	dprValue := []string{""}
+	params["dpr"] = dprValue
+
+	qValues := []string{qValue}
+	if quality != nil || qValue != "" {
+		params["q"] = qValues
+	}
+
+	// The ratios are iterated over "in order," so that the srcset is
+	// deterministic, i.e. 1x always comes before 5x.
+	for idx, dpr := range dprs {
+		if idx > 0 {
+			dst = append(dst, srcsetSeparator...)
+		}
+
+		dprValue[0] = formatDPR(dpr)
+		if quality != nil && qValue == "" {
+			q, err := qualityOf(quality, dpr)
+			if err != nil {
+				return dst, err
+			}
+			qValues[0] = q
+		}
+
+		dst = b.appendCandidate(dst, *pathBuf, params, DensityDescriptor, dpr, entries)
+	}
+	return dst, nil
 }
 
+// srcsetSeparator separates the image candidate strings of a srcset.
+// For more information see:
+// https://html.spec.whatwg.org/multipage/images.html#srcset-attributes
+const srcsetSeparator = SrcsetNewlineSeparator
+
 // TargetWidths creates an array of integer image widths.
 // The image widths begin at the minWidth value and end at the
 // maxWidth value––with a defaultTolerance amount of tolerable image
 // width-variance between them.
+//
+// If the range or tolerance is invalid, TargetWidths calls log.Fatal;
+// use TargetWidthsE to handle the error instead.
 func TargetWidths(minWidth int, maxWidth int, tolerance float64) []int {
-	validRange, err := validateRangeWithTolerance(minWidth, maxWidth, tolerance)
+	resolutions, err := TargetWidthsE(minWidth, maxWidth, tolerance)
 	if err != nil {
 		log.Fatalln(err)
 	}
+	return resolutions
+}
+
+// TargetWidthsE functions like TargetWidths except that it returns an
+// error, rather than exiting, if the range or tolerance is invalid. The
+// error matches ErrInvalidWidthRange or ErrInvalidTolerance when compared
+// with errors.Is.
+func TargetWidthsE(minWidth int, maxWidth int, tolerance float64) ([]int, error) {
+	validRange, err := validateRangeWithTolerance(minWidth, maxWidth, tolerance)
+	if err != nil {
+		return nil, err
+	}
 	begin := validRange.minWidth
 	end := validRange.maxWidth
 	tol := validRange.tolerance
 
 	if isNotCustom(begin, end, tol) {
-		return DefaultWidths
+		return DefaultWidths, nil
 	}
 
 	if begin == end {
-		return []int{begin}
+		return []int{begin}, nil
 	}
 	var resolutions []int
 	var start = float64(begin)
@@ -215,7 +750,7 @@ func TargetWidths(minWidth int, maxWidth int, tolerance float64) []int {
 	if resolutions != nil && resolutions[lengthOfResolutions-1] < end {
 		resolutions = append(resolutions, end)
 	}
-	return resolutions
+	return resolutions, nil
 }
 
 // isNotCustom takes minWidth, maxWidth, and tolerance values and
@@ -1,8 +1,11 @@
 package imgix
 
 import (
+	"bytes"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 func testClient() URLBuilder {
@@ -201,3 +204,457 @@ func TestURLBuilder_CreateSrcsetQOverridesWithoutVariableQuality(t *testing.T) {
 		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
 	}
 }
+
+func TestURLBuilder_CreateSrcsetDevicePixelRatios(t *testing.T) {
+	c := testClient()
+	params := []IxParam{Param("w", "320")}
+
+	want := "https://test.imgix.net/image.png?dpr=1&q=75&w=320 1x,\n" +
+		"https://test.imgix.net/image.png?dpr=1.5&q=63&w=320 1.5x,\n" +
+		"https://test.imgix.net/image.png?dpr=2&q=50&w=320 2x,\n" +
+		"https://test.imgix.net/image.png?dpr=2.5&q=43&w=320 2.5x"
+
+	got := c.CreateSrcset("image.png", params, WithDevicePixelRatios(2.5, 1, 2, 1.5, 2))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetDPRQualities(t *testing.T) {
+	c := testClient()
+	params := []IxParam{Param("w", "320")}
+
+	want := "https://test.imgix.net/image.png?dpr=1&q=90&w=320 1x,\n" +
+		"https://test.imgix.net/image.png?dpr=1.5&q=63&w=320 1.5x,\n" +
+		"https://test.imgix.net/image.png?dpr=2&q=60&w=320 2x"
+
+	got := c.CreateSrcset("image.png", params,
+		WithDevicePixelRatios(1, 1.5, 2),
+		WithDPRQualities(map[float64]int{1: 90, 2: 60}))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+
+	// A q param still overrides the qualities.
+	got = c.CreateSrcset("image.png", append(params, Param("q", "99")),
+		WithDevicePixelRatios(1, 2),
+		WithDPRQualities(map[float64]int{1: 90, 2: 60}))
+
+	want = "https://test.imgix.net/image.png?dpr=1&q=99&w=320 1x,\n" +
+		"https://test.imgix.net/image.png?dpr=2&q=99&w=320 2x"
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetDPRQualityFunc(t *testing.T) {
+	c := testClient()
+	params := []IxParam{Param("h", "320")}
+
+	want := "https://test.imgix.net/image.png?dpr=1&h=320&q=80 1x,\n" +
+		"https://test.imgix.net/image.png?dpr=2&h=320&q=40 2x"
+
+	got := c.CreateSrcset("image.png", params,
+		WithDevicePixelRatios(1, 2),
+		WithDPRQualityFunc(func(dpr float64) int { return int(80 / dpr) }))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+
+	want = "https://test.imgix.net/image.png?dpr=1&h=320 1x,\n" +
+		"https://test.imgix.net/image.png?dpr=2&h=320 2x"
+
+	got = c.CreateSrcset("image.png", params,
+		WithDevicePixelRatios(1, 2),
+		WithDPRQualityFunc(func(dpr float64) int { return int(80 / dpr) }),
+		WithVariableQuality(false))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetEInvalidDPR(t *testing.T) {
+	c := testClient()
+	params := []IxParam{Param("w", "320")}
+
+	cases := []struct {
+		options []SrcsetOption
+		want    error
+	}{
+		{[]SrcsetOption{WithDevicePixelRatios()}, ErrInvalidDPR},
+		{[]SrcsetOption{WithDevicePixelRatios(1, 0)}, ErrInvalidDPR},
+		{[]SrcsetOption{WithDevicePixelRatios(1, 11)}, ErrInvalidDPR},
+		{[]SrcsetOption{WithDPRQualityFunc(func(float64) int { return 101 })}, ErrInvalidQuality},
+		{[]SrcsetOption{WithDPRQualities(map[float64]int{2: -1})}, ErrInvalidQuality},
+	}
+
+	for _, tc := range cases {
+		got, err := c.CreateSrcsetE("image.png", params, tc.options...)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("got: %v; want: errors.Is(err, %v)", err, tc.want)
+		}
+
+		if got != "" {
+			t.Errorf("got: %s; want: empty srcset", got)
+		}
+	}
+}
+
+func TestURLBuilder_CreateSrcsetAspectRatioFluid(t *testing.T) {
+	c := testClient()
+	params := []IxParam{Param("fit", "crop")}
+
+	want := "https://test.imgix.net/image.png?fit=crop&h=56&w=100 100w,\n" +
+		"https://test.imgix.net/image.png?fit=crop&h=65&w=116 116w,\n" +
+		"https://test.imgix.net/image.png?fit=crop&h=76&w=135 135w"
+
+	got := c.CreateSrcset("image.png", params,
+		WithAspectRatio("16:9"), WithMinWidth(100), WithMaxWidth(135))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetAspectRatioFixedHeight(t *testing.T) {
+	c := testClient()
+	params := []IxParam{Param("h", "180"), Param("fit", "crop")}
+
+	want := "https://test.imgix.net/image.png?fit=crop&h=180&q=75&w=320 320w,\n" +
+		"https://test.imgix.net/image.png?fit=crop&h=270&q=63&w=480 480w,\n" +
+		"https://test.imgix.net/image.png?fit=crop&h=360&q=50&w=640 640w"
+
+	got := c.CreateSrcset("image.png", params,
+		WithAspectRatio("16:9"), WithDevicePixelRatios(1, 1.5, 2))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetAspectRatioFixedWidth(t *testing.T) {
+	c := testClient()
+	params := []IxParam{Param("w", "100"), Param("fit", "crop")}
+
+	want := "https://test.imgix.net/image.png?fit=crop&h=133&w=100 100w,\n" +
+		"https://test.imgix.net/image.png?fit=crop&h=267&w=200 200w"
+
+	got := c.CreateSrcset("image.png", params,
+		WithAspectRatio("3:4"), WithDevicePixelRatios(1, 2), WithVariableQuality(false))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetEInvalidAspectRatio(t *testing.T) {
+	c := testClient()
+
+	for _, ratio := range []string{"16", "16:0", "16:9:1", "a:b", "-4:3", "16/9"} {
+		got, err := c.CreateSrcsetE("image.png", []IxParam{}, WithAspectRatio(ratio))
+		if !errors.Is(err, ErrInvalidAspectRatio) {
+			t.Errorf("%s\ngot: %v; want: errors.Is(err, ErrInvalidAspectRatio)", ratio, err)
+		}
+
+		if got != "" {
+			t.Errorf("got: %s; want: empty srcset", got)
+		}
+	}
+}
+
+func TestURLBuilder_CreateSrcsetIntrinsicSizeFluid(t *testing.T) {
+	c := testClient()
+
+	cases := []struct {
+		width     int
+		height    int
+		options   []SrcsetOption
+		wantLen   int
+		wantLast  string
+		wantWidth float64
+	}{
+		{1200, 800, nil, 18, "https://test.imgix.net/image.png?w=1200", 1200},
+		{1075, 0, nil, 17, "https://test.imgix.net/image.png?w=1075", 1075},
+		{10000, 0, nil, len(DefaultWidths), "https://test.imgix.net/image.png?w=8192", 8192},
+		{0, 450, []SrcsetOption{WithAspectRatio("16:9")}, 16, "https://test.imgix.net/image.png?h=450&w=800", 800},
+	}
+
+	for _, tc := range cases {
+		options := append(tc.options, WithIntrinsicSize(tc.width, tc.height))
+		entries := c.CreateSrcsetEntries("image.png", nil, options...)
+
+		if len(entries) != tc.wantLen {
+			t.Fatalf("%dx%d\ngot:  %d entries\nwant: %d entries", tc.width, tc.height, len(entries), tc.wantLen)
+		}
+
+		last := entries[len(entries)-1]
+		if last.URL != tc.wantLast || last.Value != tc.wantWidth {
+			t.Errorf("\ngot:  %s\nwant: %s %vw", last, tc.wantLast, tc.wantWidth)
+		}
+	}
+
+	if DefaultWidths[len(DefaultWidths)-1] != 8192 {
+		t.Errorf("got: %v; want: DefaultWidths unchanged", DefaultWidths)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetIntrinsicSizeFixed(t *testing.T) {
+	c := testClient()
+
+	want := "https://test.imgix.net/image.png?dpr=1&q=75&w=320 1x,\n" +
+		"https://test.imgix.net/image.png?dpr=2&q=50&w=320 2x,\n" +
+		"https://test.imgix.net/image.png?dpr=3&q=35&w=320 3x"
+	got := c.CreateSrcset("image.png", []IxParam{Param("w", "320")}, WithIntrinsicSize(1000, 0))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+
+	// The smallest ratio is kept, even if it is too large.
+	want = "https://test.imgix.net/image.png?dpr=1&h=800&q=75 1x"
+	got = c.CreateSrcset("image.png", []IxParam{Param("h", "800")}, WithIntrinsicSize(1000, 600))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+
+	want = "https://test.imgix.net/image.png?h=180&q=75&w=320 320w,\n" +
+		"https://test.imgix.net/image.png?h=360&q=50&w=640 640w"
+	got = c.CreateSrcset("image.png", []IxParam{Param("h", "180")},
+		WithAspectRatio("16:9"), WithIntrinsicSize(1000, 400))
+
+	if got != want {
+		t.Errorf("\ngot:  %s\n\nwant: %s", got, want)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetEInvalidIntrinsicSize(t *testing.T) {
+	c := testClient()
+	got, err := c.CreateSrcsetE("image.png", []IxParam{}, WithIntrinsicSize(-1, 600))
+	if !errors.Is(err, ErrInvalidIntrinsicSize) {
+		t.Errorf("got: %v; want: errors.Is(err, ErrInvalidIntrinsicSize)", err)
+	}
+
+	if got != "" {
+		t.Errorf("got: %s; want: empty srcset", got)
+	}
+}
+
+func TestURLBuilder_TargetWidthsE(t *testing.T) {
+	got, err := TargetWidthsE(100, 108, 0.02)
+	if err != nil {
+		t.Fatalf("got: err == %v; want: err == nil", err)
+	}
+
+	want := []int{100, 104, 108}
+	if len(got) != len(want) {
+		t.Fatalf("got: %v; want: %v", got, want)
+	}
+	for idx, v := range want {
+		if got[idx] != v {
+			t.Errorf("got: %v; want: %v; arrays differ at index %d", got[idx], v, idx)
+		}
+	}
+
+	_, err = TargetWidthsE(380, 100, 0.08)
+	if !errors.Is(err, ErrInvalidWidthRange) {
+		t.Errorf("got: %v; want: errors.Is(err, ErrInvalidWidthRange)", err)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetEInvalidTolerance(t *testing.T) {
+	c := testClient()
+	got, err := c.CreateSrcsetE("image.png", []IxParam{}, WithTolerance(0.001))
+	if !errors.Is(err, ErrInvalidTolerance) {
+		t.Errorf("got: %v; want: errors.Is(err, ErrInvalidTolerance)", err)
+	}
+
+	if got != "" {
+		t.Errorf("got: %s; want: empty srcset", got)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetFromWidthsEInvalidWidth(t *testing.T) {
+	c := testClient()
+	got, err := c.CreateSrcsetFromWidthsE("image.png", []IxParam{}, []int{100, -200})
+	if !errors.Is(err, ErrInvalidWidth) {
+		t.Errorf("got: %v; want: errors.Is(err, ErrInvalidWidth)", err)
+	}
+
+	if got != "" {
+		t.Errorf("got: %s; want: empty srcset", got)
+	}
+
+	// CreateSrcsetFromWidths builds no srcset rather than exiting.
+	got = c.CreateSrcsetFromWidths("image.png", []IxParam{}, []int{100, -200, 300})
+	if got != "" {
+		t.Errorf("got: %s; want: empty srcset", got)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetExpiresAt(t *testing.T) {
+	c := testClient()
+	params := []IxParam{Param("w", "320"), ExpiresAt(time.Unix(1700000000, 0))}
+	srcset := c.CreateSrcset("image.png", params)
+
+	for _, entry := range strings.Split(srcset, ",\n") {
+		if !strings.Contains(entry, "expires=1700000000&") {
+			t.Errorf("got: %s; want: expires=1700000000", entry)
+		}
+	}
+}
+
+func TestURLBuilder_CreateSrcsetWithDefaultParams(t *testing.T) {
+	c := NewURLBuilder("test.imgix.net",
+		WithLibParam(false),
+		WithDefaultParams(Param("auto", "format"), Param("q", "75")))
+
+	got := c.CreateSrcsetFromWidths("image.jpg", []IxParam{Without("q")}, []int{100, 200})
+	want := "https://test.imgix.net/image.jpg?auto=format&w=100 100w,\n" +
+		"https://test.imgix.net/image.jpg?auto=format&w=200 200w"
+	if got != want {
+		t.Errorf("\ngot: \n%s\n\nwant: \n%s", got, want)
+	}
+
+	// With variable quality, a default q gives way to the dpr-based
+	// quality, while a q passed to the call takes precedence over it.
+	got = c.CreateSrcset("image.png", []IxParam{Param("w", "320")})
+	want = "https://test.imgix.net/image.png?auto=format&dpr=1&q=75&w=320 1x,\n" +
+		"https://test.imgix.net/image.png?auto=format&dpr=2&q=50&w=320 2x,\n" +
+		"https://test.imgix.net/image.png?auto=format&dpr=3&q=35&w=320 3x,\n" +
+		"https://test.imgix.net/image.png?auto=format&dpr=4&q=23&w=320 4x,\n" +
+		"https://test.imgix.net/image.png?auto=format&dpr=5&q=20&w=320 5x"
+	if got != want {
+		t.Errorf("\ngot: \n%s\n\nwant: \n%s", got, want)
+	}
+
+	got = c.CreateSrcset("image.png", []IxParam{Param("w", "320"), Param("q", "60")})
+	if strings.Count(got, "q=60") != 5 {
+		t.Errorf("got: \n%s\n\nwant: q=60 in every entry", got)
+	}
+
+	// Without variable quality, the default q applies.
+	got = c.CreateSrcset("image.png", []IxParam{Param("w", "320")}, WithVariableQuality(false))
+	if strings.Count(got, "q=75") != 5 {
+		t.Errorf("got: \n%s\n\nwant: q=75 in every entry", got)
+	}
+}
+
+func TestURLBuilder_CreateSrcsetWithDropDefaults(t *testing.T) {
+	c := NewURLBuilder("test.imgix.net", WithLibParam(false), WithDropDefaults(true))
+
+	// An explicit q=75 keeps variable quality from lowering the quality of
+	// the 2x candidate, though it is dropped from each URL as the default.
+	params := []IxParam{Param("w", "320"), Param("q", "75")}
+	got := c.CreateSrcset("image.png", params, WithDevicePixelRatios(1, 2))
+	want := "https://test.imgix.net/image.png?w=320 1x,\n" +
+		"https://test.imgix.net/image.png?dpr=2&w=320 2x"
+	if got != want {
+		t.Errorf("\ngot: \n%s\n\nwant: \n%s", got, want)
+	}
+
+	entries := c.CreateSrcsetEntries("image.png", params, WithDevicePixelRatios(1, 2))
+	if q := entries[1].Params.Get("q"); q != "" {
+		t.Errorf("\ngot:  q=%s\nwant: no q", q)
+	}
+
+	got = c.CreateSrcset("image.png", []IxParam{Param("w", "320")}, WithDevicePixelRatios(1, 2))
+	want = "https://test.imgix.net/image.png?w=320 1x,\n" +
+		"https://test.imgix.net/image.png?dpr=2&q=50&w=320 2x"
+	if got != want {
+		t.Errorf("\ngot: \n%s\n\nwant: \n%s", got, want)
+	}
+}
+
+func BenchmarkURLBuilder_CreateSrcset(b *testing.B) {
+	c := NewURLBuilder("my-social-network.imgix.net", WithToken("FOO123bar"))
+	params := []IxParam{Param("auto", "format", "compress")}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.CreateSrcset("users/1.png", params)
+	}
+}
+
+func BenchmarkURLBuilder_CreateSrcsetDpr(b *testing.B) {
+	c := NewURLBuilder("my-social-network.imgix.net", WithToken("FOO123bar"))
+	params := []IxParam{Param("w", "320"), Param("auto", "format", "compress")}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.CreateSrcset("users/1.png", params)
+	}
+}
+
+// TestURLBuilder_CreateSrcsetAllocs guards against regressions in the
+// number of allocations made per image candidate string. The budgets
+// leave headroom for the race detector, which drops pooled buffers at
+// random.
+func TestURLBuilder_CreateSrcsetAllocs(t *testing.T) {
+	c := NewURLBuilder("my-social-network.imgix.net", WithToken("FOO123bar"))
+
+	const fluidBudget = 2 * 31 // Two per candidate, for the 31 default widths.
+	params := []IxParam{Param("auto", "format", "compress")}
+	allocs := testing.AllocsPerRun(100, func() {
+		c.CreateSrcset("users/1.png", params)
+	})
+
+	if allocs > fluidBudget {
+		t.Errorf("\ngot:  %v allocs\nwant: at most %v allocs", allocs, fluidBudget)
+	}
+
+	const dprBudget = 20
+	params = []IxParam{Param("w", "320"), Param("auto", "format", "compress")}
+	allocs = testing.AllocsPerRun(100, func() {
+		c.CreateSrcset("users/1.png", params)
+	})
+
+	if allocs > dprBudget {
+		t.Errorf("\ngot:  %v allocs\nwant: at most %v allocs", allocs, dprBudget)
+	}
+}
+
+func TestURLBuilder_WriteSrcset(t *testing.T) {
+	c := testClient()
+	params := []IxParam{Param("w", "320")}
+
+	var buf bytes.Buffer
+	err := c.WriteSrcset(&buf, "image.png", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := c.CreateSrcset("image.png", params)
+	if buf.String() != expected {
+		t.Errorf("\ngot:  %s\nwant: %s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	err = c.WriteSrcsetFromWidths(&buf, "image.png", []IxParam{}, []int{100, 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = c.CreateSrcsetFromWidths("image.png", []IxParam{}, []int{100, 200})
+	if buf.String() != expected {
+		t.Errorf("\ngot:  %s\nwant: %s", buf.String(), expected)
+	}
+}
+
+func TestURLBuilder_WriteSrcsetInvalid(t *testing.T) {
+	c := testClient()
+
+	var buf bytes.Buffer
+	err := c.WriteSrcset(&buf, "image.png", []IxParam{}, WithMinWidth(-1))
+	if !errors.Is(err, ErrInvalidWidthRange) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrInvalidWidthRange)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("\ngot:  %s\nwant: an empty srcset", buf.String())
+	}
+}
@@ -3,7 +3,10 @@ package imgix
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -30,8 +33,8 @@ func validateDomain(domain string) (string, error) {
 	if strings.HasPrefix(domain, "http") {
 		u, err := url.Parse(domain)
 		if err != nil {
-			return "", fmt.Errorf(
-				"failed to parse URL form from domain %s due to %w", domain, err)
+			return "", &DomainError{domain, fmt.Errorf(
+				"failed to parse URL form from domain %s due to %w", domain, err)}
 		}
 		return u.Hostname(), nil
 	}
@@ -40,11 +43,65 @@ func validateDomain(domain string) (string, error) {
 	// is parsed correctly.
 	u, err := url.Parse("https://" + domain)
 	if err != nil {
-		return "", fmt.Errorf(
-			"failed to parse domain %s with scheme: https, due to: %w", domain, err)
+		return "", &DomainError{domain, fmt.Errorf(
+			"failed to parse domain %s with scheme: https, due to: %w", domain, err)}
 	}
 	return u.Hostname(), nil
+}
+
+// validateDomainStrict checks that the domain is a bare hostname, e.g.
+// example.imgix.net. Unlike validateDomain, nothing is stripped from the
+// domain; a scheme, credentials, port, path, query, or fragment are each
+// rejected with their own error, as are empty labels and characters that
+// cannot appear in a hostname.
+func validateDomainStrict(domain string) (string, error) {
+	if domain == "" {
+		return "", &DomainError{domain, ErrDomainEmpty}
+	}
+
+	if strings.Contains(domain, "://") || strings.HasPrefix(domain, "//") {
+		return "", &DomainError{domain, ErrDomainHasScheme}
+	}
+
+	host := domain
+	if i := strings.IndexAny(domain, "/?#"); i >= 0 {
+		host = domain[:i]
+	}
+
+	if strings.Contains(host, "@") {
+		return "", &DomainError{domain, ErrDomainHasUserinfo}
+	}
+
+	if strings.Contains(host, ":") {
+		return "", &DomainError{domain, ErrDomainHasPort}
+	}
+
+	if len(host) < len(domain) {
+		switch domain[len(host)] {
+		case '/':
+			return "", &DomainError{domain, ErrDomainHasPath}
+		case '?':
+			return "", &DomainError{domain, ErrDomainHasQuery}
+		default:
+			return "", &DomainError{domain, ErrDomainHasFragment}
+		}
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if label == "" {
+			return "", &DomainError{domain, ErrDomainEmptyLabel}
+		}
+	}
 
+	for idx, r := range host {
+		isLetter := 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+		isDigit := '0' <= r && r <= '9'
+		if !isLetter && !isDigit && r != '-' && r != '.' {
+			return "", &DomainError{domain, fmt.Errorf(
+				"%w, found %q at index `%d`", ErrDomainInvalidCharacter, r, idx)}
+		}
+	}
+	return host, nil
 }
 
 // validateMinWidth checks if the value is a valid minWidth.
@@ -53,7 +110,7 @@ func validateDomain(domain string) (string, error) {
 func validateMinWidth(minWidth int) (int, error) {
 	msg := "`minWidth` value must be greater than, or equal to, zero"
 	if minWidth < 0 {
-		return -1, errors.New(msg)
+		return -1, fmt.Errorf("%w: %s", ErrInvalidWidthRange, msg)
 	}
 	return minWidth, nil
 }
@@ -64,7 +121,7 @@ func validateMinWidth(minWidth int) (int, error) {
 func validateMaxWidth(maxWidth int) (int, error) {
 	msg := "`maxWidth` value must be greater than, or equal to, zero"
 	if maxWidth < 0 {
-		return -1, errors.New(msg)
+		return -1, fmt.Errorf("%w: %s", ErrInvalidWidthRange, msg)
 	}
 	return maxWidth, nil
 }
@@ -75,9 +132,9 @@ func validateMaxWidth(maxWidth int) (int, error) {
 // is returned.
 func validateWidthTolerance(value float64) (float64, error) {
 	const onePercent = 0.01
-	msg := "`defaultTolerance`erance must be greater than, or equal to, one percent (0.01)"
+	msg := "`tolerance` must be greater than, or equal to, one percent (0.01)"
 	if value < onePercent {
-		return -1, errors.New(msg)
+		return -1, fmt.Errorf("%w: %s", ErrInvalidTolerance, msg)
 	}
 	return value, nil
 }
@@ -102,7 +159,7 @@ func validateRange(minWidth int, maxWidth int) (rangePair, error) {
 	// Check if range increases.
 	if validMax < validMin {
 		msg := "`minWidth` must be less than or equal to the `maxWidth`"
-		return rangePair{-1, -1}, errors.New(msg)
+		return rangePair{-1, -1}, fmt.Errorf("%w: %s", ErrInvalidWidthRange, msg)
 	}
 	return rangePair{validMin, validMax}, nil
 }
@@ -125,15 +182,45 @@ func validateRangeWithTolerance(minWidth int, maxWidth int, tolerance float64) (
 		tolerance: validTol}, nil
 }
 
+// maxDPR is the largest device pixel ratio imgix accepts.
+const maxDPR = 10
+
+// validateDPRs checks that every device pixel ratio is greater than zero
+// and at most maxDPR. The valid ratios are returned sorted, without
+// duplicates, so that srcsets are deterministic.
+func validateDPRs(dprs []float64) ([]float64, error) {
+	if len(dprs) == 0 {
+		return nil, fmt.Errorf("%w: at least one ratio must be given", ErrInvalidDPR)
+	}
+
+	sorted := make([]float64, 0, len(dprs))
+	for idx, dpr := range dprs {
+		if !(dpr > 0 && dpr <= maxDPR) {
+			return nil, fmt.Errorf("%w: ratios must be greater than zero and at most %d, found %v at index `%d`",
+				ErrInvalidDPR, maxDPR, dpr, idx)
+		}
+		sorted = append(sorted, dpr)
+	}
+	sort.Float64s(sorted)
+
+	unique := sorted[:1]
+	for _, dpr := range sorted[1:] {
+		if dpr != unique[len(unique)-1] {
+			unique = append(unique, dpr)
+		}
+	}
+	return unique, nil
+}
+
 // validateWidths checks that an array is comprised of only positive
-// integers. An error is when the first negative value is encountered.
+// integers. An error matching ErrInvalidWidth is returned when the first
+// negative value is encountered.
 func validateWidths(widthValues []int) ([]int, error) {
 	idx, allPositive := allPositive(widthValues)
 
 	if !allPositive {
-		msg := fmt.Sprintf("width values must be positive, "+
-			"found negative width at index `%d`", idx)
-		return []int{}, errors.New(msg)
+		return []int{}, fmt.Errorf("%w: width values must be positive, "+
+			"found negative width at index `%d`", ErrInvalidWidth, idx)
 	}
 	return widthValues, nil
 }
@@ -149,3 +236,248 @@ func allPositive(values []int) (int, bool) {
 	}
 	return idx, true
 }
+
+// validateParams checks every param against the imgix parameter spec. If
+// any params are rejected, a *ParamsError listing each of them, sorted by
+// key, is returned.
+func validateParams(params url.Values) error {
+	var problems []*ParamError
+	for _, k := range sortedKeys(params) {
+		value := strings.Join(params[k], ",")
+		if err := validateParam(k, value); err != nil {
+			problems = append(problems, &ParamError{Key: k, Value: value, Err: err})
+		}
+	}
+
+	if problems != nil {
+		return &ParamsError{Errors: problems}
+	}
+	return nil
+}
+
+// validateParam checks a single param, whose values have been joined by
+// commas. Aliases are checked as their canonical params, and the value of
+// a base64 variant (e.g. "txt64") is checked as the value of its param
+// before encoding.
+func validateParam(key string, value string) error {
+	spec, ok := lookupParamSpec(key)
+	if !ok && strings.HasSuffix(key, "64") {
+		spec, ok = lookupParamSpec(strings.TrimSuffix(key, "64"))
+		if ok && !spec.base64 {
+			return ErrParamNotBase64
+		}
+	}
+
+	if !ok {
+		return ErrUnknownParam
+	}
+
+	// The value is valid if it matches any of the expectations. Otherwise,
+	// the most specific problem is reported: a value that has the right
+	// form but is out of range says more than one that is malformed.
+	var problem error
+	for _, e := range spec.expects {
+		err := e.check(value)
+		if err == nil {
+			return nil
+		}
+
+		if problem == nil || errors.Is(problem, ErrParamMalformed) && !errors.Is(err, ErrParamMalformed) {
+			problem = err
+		}
+	}
+	return problem
+}
+
+// lookupParamSpec returns the spec of the param with the given key, which
+// may be an alias.
+func lookupParamSpec(key string) (paramSpec, bool) {
+	if canonical, ok := paramAliases[key]; ok {
+		key = canonical
+	}
+	spec, ok := paramSpecs[key]
+	return spec, ok
+}
+
+// check returns an error if the value does not match the expectation.
+func (e paramExpectation) check(value string) error {
+	switch e.kind {
+	case kindInteger, kindTimestamp:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: must be an integer", ErrParamMalformed)
+		}
+		if e.kind == kindTimestamp && i < 0 {
+			return fmt.Errorf("%w: must not be negative", ErrParamOutOfRange)
+		}
+		if err := e.checkValues(value); err != nil {
+			return err
+		}
+		return e.checkRange(float64(i))
+
+	case kindNumber, kindUnitScalar:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%w: must be a number", ErrParamMalformed)
+		}
+		if e.kind == kindUnitScalar && (f < 0 || f > 1) {
+			return fmt.Errorf("%w: must be between 0 and 1", ErrParamOutOfRange)
+		}
+		return e.checkRange(f)
+
+	case kindList:
+		for _, v := range strings.Split(value, ",") {
+			if err := e.checkValues(v); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case kindHexColor:
+		if !isHexColor(value) {
+			return fmt.Errorf("%w: must be a 3, 4, 6, or 8 digit hex color", ErrParamMalformed)
+		}
+		return nil
+
+	case kindColorKeyword:
+		if !colorKeywords[strings.ToLower(value)] {
+			return fmt.Errorf("%w: must be a CSS color keyword", ErrParamMalformed)
+		}
+		return nil
+
+	case kindRatio:
+		if !isRatio(value) {
+			return fmt.Errorf("%w: must be a ratio of two positive numbers, e.g. 16:9", ErrParamMalformed)
+		}
+		return nil
+
+	case kindURL:
+		if value == "" {
+			return fmt.Errorf("%w: must not be empty", ErrParamMalformed)
+		}
+		return nil
+
+	default:
+		return e.checkValues(value)
+	}
+}
+
+// checkValues returns an error if the expectation has possible values and
+// the value is not one of them.
+func (e paramExpectation) checkValues(value string) error {
+	if len(e.values) == 0 {
+		return nil
+	}
+
+	for _, v := range e.values {
+		if value == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: found %q, want one of %s",
+		ErrParamNotAllowed, value, strings.Join(e.values, ", "))
+}
+
+// checkRange returns an error if the number is outside the expectation's
+// range.
+func (e paramExpectation) checkRange(f float64) error {
+	min := strconv.FormatFloat(e.min, 'f', -1, 64)
+	max := strconv.FormatFloat(e.max, 'f', -1, 64)
+
+	switch {
+	case e.hasMin && e.hasMax && (f < e.min || f > e.max):
+		return fmt.Errorf("%w: must be between %s and %s", ErrParamOutOfRange, min, max)
+	case e.hasMin && f < e.min:
+		return fmt.Errorf("%w: must be at least %s", ErrParamOutOfRange, min)
+	case e.hasMax && f > e.max:
+		return fmt.Errorf("%w: must be at most %s", ErrParamOutOfRange, max)
+	}
+	return nil
+}
+
+// isHexColor reports whether s is a hex color of 3 (RGB), 4 (ARGB),
+// 6 (RRGGBB), or 8 (AARRGGBB) digits.
+func isHexColor(s string) bool {
+	switch len(s) {
+	case 3, 4, 6, 8:
+	default:
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		isHex := '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+		if !isHex {
+			return false
+		}
+	}
+	return true
+}
+
+// isRatio reports whether s is an aspect ratio of the form W:H, where W
+// and H are positive numbers, e.g. "16:9" or "1.5:1".
+func isRatio(s string) bool {
+	_, _, ok := parseRatio(s)
+	return ok
+}
+
+// parseRatio parses an aspect ratio of the form W:H, where W and H are
+// positive numbers. It reports whether s is such a ratio.
+func parseRatio(s string) (w float64, h float64, ok bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	var sides [2]float64
+	for idx, part := range parts {
+		f, err := strconv.ParseFloat(part, 64)
+		if err != nil || !(f > 0) || math.IsInf(f, 0) {
+			return 0, 0, false
+		}
+		sides[idx] = f
+	}
+	return sides[0], sides[1], true
+}
+
+// colorKeywords contains the CSS color keywords accepted by color params.
+var colorKeywords = map[string]bool{
+	"aliceblue": true, "antiquewhite": true, "aqua": true, "aquamarine": true,
+	"azure": true, "beige": true, "bisque": true, "black": true,
+	"blanchedalmond": true, "blue": true, "blueviolet": true, "brown": true,
+	"burlywood": true, "cadetblue": true, "chartreuse": true, "chocolate": true,
+	"coral": true, "cornflowerblue": true, "cornsilk": true, "crimson": true,
+	"cyan": true, "darkblue": true, "darkcyan": true, "darkgoldenrod": true,
+	"darkgray": true, "darkgreen": true, "darkgrey": true, "darkkhaki": true,
+	"darkmagenta": true, "darkolivegreen": true, "darkorange": true, "darkorchid": true,
+	"darkred": true, "darksalmon": true, "darkseagreen": true, "darkslateblue": true,
+	"darkslategray": true, "darkslategrey": true, "darkturquoise": true, "darkviolet": true,
+	"deeppink": true, "deepskyblue": true, "dimgray": true, "dimgrey": true,
+	"dodgerblue": true, "firebrick": true, "floralwhite": true, "forestgreen": true,
+	"fuchsia": true, "gainsboro": true, "ghostwhite": true, "gold": true,
+	"goldenrod": true, "gray": true, "green": true, "greenyellow": true,
+	"grey": true, "honeydew": true, "hotpink": true, "indianred": true,
+	"indigo": true, "ivory": true, "khaki": true, "lavender": true,
+	"lavenderblush": true, "lawngreen": true, "lemonchiffon": true, "lightblue": true,
+	"lightcoral": true, "lightcyan": true, "lightgoldenrodyellow": true, "lightgray": true,
+	"lightgreen": true, "lightgrey": true, "lightpink": true, "lightsalmon": true,
+	"lightseagreen": true, "lightskyblue": true, "lightslategray": true, "lightslategrey": true,
+	"lightsteelblue": true, "lightyellow": true, "lime": true, "limegreen": true,
+	"linen": true, "magenta": true, "maroon": true, "mediumaquamarine": true,
+	"mediumblue": true, "mediumorchid": true, "mediumpurple": true, "mediumseagreen": true,
+	"mediumslateblue": true, "mediumspringgreen": true, "mediumturquoise": true, "mediumvioletred": true,
+	"midnightblue": true, "mintcream": true, "mistyrose": true, "moccasin": true,
+	"navajowhite": true, "navy": true, "oldlace": true, "olive": true,
+	"olivedrab": true, "orange": true, "orangered": true, "orchid": true,
+	"palegoldenrod": true, "palegreen": true, "paleturquoise": true, "palevioletred": true,
+	"papayawhip": true, "peachpuff": true, "peru": true, "pink": true,
+	"plum": true, "powderblue": true, "purple": true, "rebeccapurple": true,
+	"red": true, "rosybrown": true, "royalblue": true, "saddlebrown": true,
+	"salmon": true, "sandybrown": true, "seagreen": true, "seashell": true,
+	"sienna": true, "silver": true, "skyblue": true, "slateblue": true,
+	"slategray": true, "slategrey": true, "snow": true, "springgreen": true,
+	"steelblue": true, "tan": true, "teal": true, "thistle": true,
+	"tomato": true, "transparent": true, "turquoise": true, "violet": true,
+	"wheat": true, "white": true, "whitesmoke": true, "yellow": true,
+	"yellowgreen": true,
+}
@@ -1,6 +1,9 @@
 package imgix
 
 import (
+	"errors"
+	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -8,10 +11,10 @@ func TestValidators_validateNegativeWidths(t *testing.T) {
 	widths := []int{100, 200, 300, -400, -500}
 	got, err := validateWidths(widths)
 
-	// Assert an error occurred, i.e. that the `err` is NOT `nil`.
-	// If the err is nil, fail.
-	if err == nil {
-		t.Errorf("got: err == nil; want: err != nil")
+	// Assert an error occurred, i.e. that the `err` is NOT `nil`,
+	// and that it can be matched with errors.Is. If not, fail.
+	if !errors.Is(err, ErrInvalidWidth) {
+		t.Errorf("got: %v; want: errors.Is(err, ErrInvalidWidth)", err)
 	}
 
 	want := []int{}
@@ -153,3 +156,174 @@ func TestValidators_validateRangeWithToleranceValid(t *testing.T) {
 		t.Errorf("got: %v; want: %v", got.tolerance, want)
 	}
 }
+
+func TestValidators_validateRangeErrorsMatchSentinels(t *testing.T) {
+	_, err := validateRangeWithTolerance(-1, 200, 0.08)
+	if !errors.Is(err, ErrInvalidWidthRange) {
+		t.Errorf("got: %v; want: errors.Is(err, ErrInvalidWidthRange)", err)
+	}
+
+	_, err = validateRangeWithTolerance(740, 320, 0.08)
+	if !errors.Is(err, ErrInvalidWidthRange) {
+		t.Errorf("got: %v; want: errors.Is(err, ErrInvalidWidthRange)", err)
+	}
+
+	_, err = validateRangeWithTolerance(100, 200, 0.001)
+	if !errors.Is(err, ErrInvalidTolerance) {
+		t.Errorf("got: %v; want: errors.Is(err, ErrInvalidTolerance)", err)
+	}
+}
+
+func TestValidators_validateDomainStrictValid(t *testing.T) {
+	const want = "my-source.imgix.net"
+	got, err := validateDomainStrict(want)
+
+	if err != nil {
+		t.Errorf("got: err == %v; want: err == nil", err)
+	}
+
+	if got != want {
+		t.Errorf("got: %v; want: %v", got, want)
+	}
+}
+
+func TestValidators_validateDomainStrictInvalid(t *testing.T) {
+	cases := []struct {
+		domain string
+		want   error
+	}{
+		{"", ErrDomainEmpty},
+		{"https://example.imgix.net", ErrDomainHasScheme},
+		{"//example.imgix.net", ErrDomainHasScheme},
+		{"user:pass@example.imgix.net", ErrDomainHasUserinfo},
+		{"example.imgix.net:8080", ErrDomainHasPort},
+		{"example.imgix.net/foo", ErrDomainHasPath},
+		{"example.imgix.net/", ErrDomainHasPath},
+		{"example.imgix.net?w=100", ErrDomainHasQuery},
+		{"example.imgix.net#top", ErrDomainHasFragment},
+		{"example..imgix.net", ErrDomainEmptyLabel},
+		{"example.imgix.net.", ErrDomainEmptyLabel},
+		{"exa_mple.imgix.net", ErrDomainInvalidCharacter},
+		{"exämple.imgix.net", ErrDomainInvalidCharacter},
+	}
+
+	for _, c := range cases {
+		_, err := validateDomainStrict(c.domain)
+
+		if !errors.Is(err, c.want) {
+			t.Errorf("%q\ngot:  %v\nwant: %v", c.domain, err, c.want)
+		}
+
+		if !errors.Is(err, ErrInvalidDomain) {
+			t.Errorf("%q\ngot:  %v\nwant: errors.Is(err, ErrInvalidDomain)", c.domain, err)
+		}
+	}
+}
+
+func TestValidators_domainErrorMessage(t *testing.T) {
+	_, err := NewURLBuilderE("example.imgix.net:8080", WithStrictDomain(true))
+
+	want := "imgix: invalid domain \"example.imgix.net:8080\": domain must not contain a port"
+	if err == nil || err.Error() != want {
+		t.Errorf("\ngot:  %v\nwant: %s", err, want)
+	}
+
+	if got := ErrDomainHasPort.Error(); !strings.HasPrefix(got, "imgix: ") {
+		t.Errorf("\ngot:  %s\nwant: prefix imgix: ", got)
+	}
+}
+
+func TestValidators_validateParamValid(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"w", "320"},
+		{"w", "0.5"},
+		{"q", "100"},
+		{"dpr", "1.5"},
+		{"fit", "crop"},
+		{"auto", "format,compress"},
+		{"bg", "FF0000"},
+		{"bg", "80ff0000"},
+		{"bg", "red"},
+		{"ar", "16:9"},
+		{"ar", "1.5:1"},
+		{"or", "90"},
+		{"txt", "Hello, World!"},
+		{"txt64", "Hello, World!"},
+		{"expires", "1700000000"},
+		{"orient", "6"},
+	}
+
+	for _, tt := range tests {
+		if err := validateParam(tt.key, tt.value); err != nil {
+			t.Errorf("%s=%s: unexpected error %v", tt.key, tt.value, err)
+		}
+	}
+}
+
+func TestValidators_validateParamInvalid(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		want  error
+	}{
+		{"fti", "crop", ErrUnknownParam},
+		{"foo64", "bar", ErrUnknownParam},
+		{"w64", "320", ErrParamNotBase64},
+		{"fit", "cover", ErrParamNotAllowed},
+		{"auto", "format,zip", ErrParamNotAllowed},
+		{"or", "45", ErrParamNotAllowed},
+		{"q", "101", ErrParamOutOfRange},
+		{"w", "-320", ErrParamOutOfRange},
+		{"fp-x", "1.5", ErrParamOutOfRange},
+		{"w", "wide", ErrParamMalformed},
+		{"dpr", "NaN", ErrParamMalformed},
+		{"bg", "FF0000F", ErrParamMalformed},
+		{"bg", "#FF0000", ErrParamMalformed},
+		{"bg", "reddish", ErrParamMalformed},
+		{"ar", "16x9", ErrParamMalformed},
+		{"ar", "16:0", ErrParamMalformed},
+	}
+
+	for _, tt := range tests {
+		err := validateParam(tt.key, tt.value)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s=%s:\ngot:  %v\nwant: %v", tt.key, tt.value, err, tt.want)
+		}
+	}
+}
+
+func TestValidators_validateParamsReportsEveryProblem(t *testing.T) {
+	params := url.Values{
+		"w":   []string{"-1"},
+		"fti": []string{"crop"},
+		"q":   []string{"75"},
+		"fm":  []string{"bmp"},
+	}
+
+	err := validateParams(params)
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("\ngot:  %v\nwant: %v", err, ErrInvalidParams)
+	}
+
+	var paramsErr *ParamsError
+	if !errors.As(err, &paramsErr) {
+		t.Fatalf("\ngot:  %T\nwant: *ParamsError", err)
+	}
+
+	var got []string
+	for _, e := range paramsErr.Errors {
+		got = append(got, e.Key)
+	}
+
+	want := []string{"fm", "fti", "w"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("\ngot:  %v\nwant: %v", got, want)
+	}
+
+	if !errors.Is(err, ErrParamOutOfRange) || !errors.Is(err, ErrUnknownParam) {
+		t.Errorf("expected %v to match each problem", err)
+	}
+}
@@ -0,0 +1,229 @@
+package imgix
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Verifier checks the signatures of signed imgix URLs, e.g. in an origin
+// that must reject URLs that have been tampered with. A Verifier is safe
+// for concurrent use.
+type Verifier struct {
+	signers []Signer         // The signers whose signatures are accepted.
+	md5Only bool             // Whether every signer is an MD5 signer.
+	now     func() time.Time // Reports the current time; defaults to time.Now.
+}
+
+// NewVerifier creates a new Verifier that checks signatures created with
+// the given token. Signatures created with any of the previousTokens are
+// accepted as well, so that URLs issued before a token was rotated keep
+// working. Empty tokens are ignored.
+func NewVerifier(token string, previousTokens ...string) Verifier {
+	var signers []Signer
+	for _, t := range append([]string{token}, previousTokens...) {
+		if t != "" {
+			signers = append(signers, newMD5Signer(t))
+		}
+	}
+	return Verifier{signers: signers, md5Only: true}
+}
+
+// NewSignerVerifier creates a new Verifier that checks signatures created
+// by the given Signer, or by any of the previousSigners. Nil signers are
+// ignored. See WithSigner.
+func NewSignerVerifier(signer Signer, previousSigners ...Signer) Verifier {
+	v := Verifier{md5Only: true}
+	for _, s := range append([]Signer{signer}, previousSigners...) {
+		if s == nil {
+			continue
+		}
+
+		if _, ok := s.(*md5Signer); !ok {
+			v.md5Only = false
+		}
+		v.signers = append(v.signers, s)
+	}
+	return v
+}
+
+// Verify checks the signature of a full URL, e.g. one created by
+// CreateURL. It returns nil if the signature is valid. Otherwise, the
+// returned error matches one of ErrMissingSignature, ErrMalformedSignature,
+// or ErrSignatureMismatch when compared with errors.Is.
+//
+// If the signature is valid but the URL has an "expires" param (see
+// ExpiresAt) that has passed, the returned error matches ErrExpired.
+func (v Verifier) Verify(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidURL, rawURL, err)
+	}
+	return v.VerifyPath(u.EscapedPath(), u.RawQuery)
+}
+
+// VerifyPath checks the signature of an escaped path and a raw query
+// string, e.g. the EscapedPath() and RawQuery of an http.Request's URL.
+// The path and query are checked exactly as they were signed, so they
+// must not be decoded or reordered. See Verify for the errors returned.
+func (v Verifier) VerifyPath(path string, rawQuery string) error {
+	if len(v.signers) == 0 {
+		return ErrMissingToken
+	}
+
+	query, signature, err := splitSignature(rawQuery)
+	if err != nil {
+		return err
+	}
+
+	// The format of MD5 signatures is known, so malformed ones can be told
+	// apart from ones that do not match. Hex digits may be in either case.
+	if v.md5Only {
+		got, err := hex.DecodeString(signature)
+		if err != nil || len(got) != md5.Size {
+			return fmt.Errorf("%w: want %d hexadecimal characters, found %q",
+				ErrMalformedSignature, md5.Size*2, signature)
+		}
+		signature = hex.EncodeToString(got)
+	}
+
+	matched := 0
+	for _, s := range v.signers {
+		want := s.Sign(path, query)
+		matched |= subtle.ConstantTimeCompare([]byte(signature), []byte(want))
+	}
+
+	if matched != 1 {
+		return fmt.Errorf("%w for path %s", ErrSignatureMismatch, path)
+	}
+	return v.checkExpiry(query)
+}
+
+// checkExpiry returns an error if the query has an "expires" param
+// that is malformed or that has passed.
+func (v Verifier) checkExpiry(query string) error {
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	expires := params.Get("expires")
+	if expires == "" {
+		return nil
+	}
+
+	timestamp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: found %q", ErrMalformedExpires, expires)
+	}
+
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+
+	expiresAt := time.Unix(timestamp, 0)
+	if !now().Before(expiresAt) {
+		return fmt.Errorf("%w at %s", ErrExpired, expiresAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// splitSignature removes the signature param from a raw query string.
+// It returns the remaining query, in its original order, along with the
+// signature value. The query must contain exactly one signature param.
+func splitSignature(rawQuery string) (query string, signature string, err error) {
+	var parts []string
+	found := false
+
+	for _, part := range strings.Split(rawQuery, "&") {
+		if !strings.HasPrefix(part, "s=") {
+			parts = append(parts, part)
+			continue
+		}
+
+		if found {
+			return "", "", fmt.Errorf("%w: found more than one `s` param", ErrMalformedSignature)
+		}
+		signature = strings.TrimPrefix(part, "s=")
+		found = true
+	}
+
+	if !found {
+		return "", "", ErrMissingSignature
+	}
+	return strings.Join(parts, "&"), signature, nil
+}
+
+// Verifier returns a Verifier that accepts signatures created with the
+// builder's token (or its Signer; see WithSigner) or any of its previous
+// tokens. For a sharded builder, domains given their own token by
+// WithDomainToken are not covered; use the builder's Verify method
+// instead.
+func (b *URLBuilder) Verifier() Verifier {
+	return b.verifierFor(shard{domain: b.domain})
+}
+
+// verifierFor returns a Verifier that accepts signatures created for the
+// given shard or with any of the builder's previous tokens.
+func (b *URLBuilder) verifierFor(s shard) Verifier {
+	previous := make([]Signer, 0, len(b.previousTokens))
+	for _, t := range b.previousTokens {
+		if t != "" {
+			previous = append(previous, newMD5Signer(t))
+		}
+	}
+	return NewSignerVerifier(b.signerFor(s), previous...)
+}
+
+// Verify checks the signature of a URL against the builder's token and
+// previous tokens. If the builder is sharded, the token of the URL's
+// domain is used. See Verifier.Verify for the errors returned.
+func (b *URLBuilder) Verify(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidURL, rawURL, err)
+	}
+
+	v := b.verifierFor(b.shardOf(u.Host))
+	return v.VerifyPath(u.EscapedPath(), u.RawQuery)
+}
+
+// Resign replaces the signature of a URL signed with one of the builder's
+// previous tokens (or its current token) with a signature created with
+// the builder's current token. The rest of the URL is left unchanged. The
+// existing signature is verified first; if it is not valid, Resign returns
+// the error from Verify.
+func (b *URLBuilder) Resign(rawURL string) (string, error) {
+	if err := b.Verify(rawURL); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %v", ErrInvalidURL, rawURL, err)
+	}
+
+	signer := b.signerFor(b.shardOf(u.Host))
+	if signer == nil {
+		return "", ErrMissingToken
+	}
+
+	path := u.EscapedPath()
+	query, _, err := splitSignature(u.RawQuery)
+	if err != nil {
+		return "", err
+	}
+
+	resigned := u.Scheme + "://" + u.Host + path + "?"
+	if query != "" {
+		resigned += query + "&"
+	}
+	resigned += "s="
+	return string(appendSignature([]byte(resigned), signer, []byte(path), []byte(query))), nil
+}
@@ -0,0 +1,190 @@
+package imgix
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify_Verify(t *testing.T) {
+	v := NewVerifier("FOO123bar")
+
+	valid := []string{
+		"https://my-social-network.imgix.net/http%3A%2F%2Favatars.com%2Fjohn-smith.png?s=493a52f008c91416351f8b33d4883135",
+		"https://my-social-network.imgix.net/users/1.png?h=300&w=400&s=1a4e48641614d1109c6a7af51be23d18",
+		"https://my-social-network.imgix.net/http%3A%2F%2Favatars.com%2Fjohn-smith.png?h=300&w=400&s=a201fe1a3caef4944dcb40f6ce99e746",
+	}
+
+	for _, rawURL := range valid {
+		if err := v.Verify(rawURL); err != nil {
+			t.Errorf("%s\ngot: err == %v; want: err == nil", rawURL, err)
+		}
+	}
+}
+
+func TestVerify_VerifyCreatedURLs(t *testing.T) {
+	u := NewURLBuilder("my-social-network.imgix.net", WithToken("FOO123bar"))
+	v := NewVerifier("FOO123bar")
+
+	urls := []string{
+		u.CreateURL(""),
+		u.CreateURL("E+P-003_D.jpeg", Param("auto", "format", "compress")),
+		u.CreateURL("~text", Param("txt64", "I cannøt belîév∑ it wors! 😱")),
+	}
+
+	for _, rawURL := range urls {
+		if err := v.Verify(rawURL); err != nil {
+			t.Errorf("%s\ngot: err == %v; want: err == nil", rawURL, err)
+		}
+	}
+}
+
+func TestVerify_VerifyPath(t *testing.T) {
+	v := NewVerifier("FOO123bar")
+	err := v.VerifyPath("/users/1.png", "h=300&w=400&s=1a4e48641614d1109c6a7af51be23d18")
+	if err != nil {
+		t.Errorf("got: err == %v; want: err == nil", err)
+	}
+}
+
+func TestVerify_VerifyInvalid(t *testing.T) {
+	v := NewVerifier("FOO123bar")
+
+	cases := []struct {
+		rawURL string
+		want   error
+	}{
+		{"https://my-social-network.imgix.net/users/1.png?h=300&w=400", ErrMissingSignature},
+		{"https://my-social-network.imgix.net/users/1.png?h=300&w=400&s=1a4e", ErrMalformedSignature},
+		{"https://my-social-network.imgix.net/users/1.png?h=300&w=400&s=zz4e48641614d1109c6a7af51be23d18", ErrMalformedSignature},
+		{"https://my-social-network.imgix.net/users/1.png?h=300&s=1a4e48641614d1109c6a7af51be23d18&s=1a4e48641614d1109c6a7af51be23d18", ErrMalformedSignature},
+		// The width has been changed from 400 to 401.
+		{"https://my-social-network.imgix.net/users/1.png?h=300&w=401&s=1a4e48641614d1109c6a7af51be23d18", ErrSignatureMismatch},
+		// The path has been changed from 1.png to 2.png.
+		{"https://my-social-network.imgix.net/users/2.png?h=300&w=400&s=1a4e48641614d1109c6a7af51be23d18", ErrSignatureMismatch},
+		{"https://my-social-network.imgix.net/users/%zz.png", ErrInvalidURL},
+	}
+
+	for _, c := range cases {
+		err := v.Verify(c.rawURL)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s\ngot:  %v\nwant: %v", c.rawURL, err, c.want)
+		}
+	}
+
+}
+
+func TestVerify_VerifyMissingToken(t *testing.T) {
+	v := NewVerifier("")
+	err := v.Verify("https://my-social-network.imgix.net/users/1.png?h=300&w=400&s=1a4e48641614d1109c6a7af51be23d18")
+	if !errors.Is(err, ErrMissingToken) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrMissingToken)
+	}
+}
+
+func TestVerify_VerifyExpires(t *testing.T) {
+	u := NewURLBuilder("my-social-network.imgix.net", WithToken("FOO123bar"))
+	expiresAt := time.Unix(1700000000, 0)
+	rawURL := u.CreateURL("users/1.png", Param("w", "400"), ExpiresAt(expiresAt))
+
+	v := NewVerifier("FOO123bar")
+	v.now = func() time.Time { return expiresAt.Add(-time.Second) }
+	if err := v.Verify(rawURL); err != nil {
+		t.Errorf("got: err == %v; want: err == nil", err)
+	}
+
+	v.now = func() time.Time { return expiresAt }
+	if err := v.Verify(rawURL); !errors.Is(err, ErrExpired) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrExpired)
+	}
+
+	// Extending the expiry invalidates the signature.
+	tampered := strings.Replace(rawURL, "expires=1700000000", "expires=1800000000", 1)
+	if err := v.Verify(tampered); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrSignatureMismatch)
+	}
+}
+
+func TestVerify_VerifyMalformedExpires(t *testing.T) {
+	u := NewURLBuilder("my-social-network.imgix.net", WithToken("FOO123bar"))
+	rawURL := u.CreateURL("users/1.png", Param("expires", "tomorrow"))
+
+	v := NewVerifier("FOO123bar")
+	if err := v.Verify(rawURL); !errors.Is(err, ErrMalformedExpires) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrMalformedExpires)
+	}
+}
+
+func TestVerify_VerifyPreviousTokens(t *testing.T) {
+	old := NewURLBuilder("my-social-network.imgix.net", WithToken("OLD123"))
+	oldURL := old.CreateURL("users/1.png", Param("w", "400"))
+
+	u := NewURLBuilder("my-social-network.imgix.net",
+		WithToken("NEW456"),
+		WithPreviousTokens("OLD123"))
+	newURL := u.CreateURL("users/1.png", Param("w", "400"))
+
+	if err := u.Verify(oldURL); err != nil {
+		t.Errorf("got: err == %v; want: err == nil", err)
+	}
+
+	if err := u.Verify(newURL); err != nil {
+		t.Errorf("got: err == %v; want: err == nil", err)
+	}
+
+	// Once the old token is retired, URLs signed with it are rejected.
+	retired := NewURLBuilder("my-social-network.imgix.net", WithToken("NEW456"))
+	if err := retired.Verify(oldURL); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrSignatureMismatch)
+	}
+}
+
+func TestVerify_PreviousTokensCopied(t *testing.T) {
+	old := NewURLBuilder("my-social-network.imgix.net", WithToken("OLD123"))
+	oldURL := old.CreateURL("users/1.png", Param("w", "400"))
+
+	tokens := []string{"OLD123"}
+	u := NewURLBuilder("my-social-network.imgix.net",
+		WithToken("NEW456"),
+		WithPreviousTokens(tokens...))
+
+	// Changing the caller's slice does not change the accepted tokens.
+	tokens[0] = "OTHER789"
+	if err := u.Verify(oldURL); err != nil {
+		t.Errorf("got: err == %v; want: err == nil", err)
+	}
+}
+
+func TestVerify_Resign(t *testing.T) {
+	old := NewURLBuilder("my-social-network.imgix.net", WithToken("OLD123"))
+	u := NewURLBuilder("my-social-network.imgix.net",
+		WithToken("NEW456"),
+		WithPreviousTokens("OLD123"))
+
+	cases := [][]IxParam{
+		{},
+		{Param("w", "400"), Param("auto", "format", "compress")},
+	}
+
+	for _, params := range cases {
+		oldURL := old.CreateURL("http://avatars.com/john-smith.png", params...)
+		want := u.CreateURL("http://avatars.com/john-smith.png", params...)
+
+		got, err := u.Resign(oldURL)
+		if err != nil {
+			t.Errorf("got: err == %v; want: err == nil", err)
+		}
+
+		if got != want {
+			t.Errorf("\ngot:  %s\nwant: %s", got, want)
+		}
+	}
+
+	// A URL signed with an unknown token is not re-signed.
+	other := NewURLBuilder("my-social-network.imgix.net", WithToken("OTHER789"))
+	_, err := u.Resign(other.CreateURL("users/1.png"))
+	if !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("\ngot:  %v\nwant: %v", err, ErrSignatureMismatch)
+	}
+}